@@ -0,0 +1,103 @@
+// Package conformance defines the recorded-market-data vector schema used
+// to replay deterministic scenarios against ExecutionEngine and the
+// baseline bots without needing a live exchange connection. Vectors live
+// as JSON files under a testdata/vectors/ directory and are loaded with
+// LoadVectors.
+package conformance
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+)
+
+// Kind identifies which input shape a Vector's Input field decodes as.
+type Kind string
+
+const (
+    KindOrderBook    Kind = "orderbook"
+    KindTradePrint   Kind = "trade"
+    KindMempoolBundle Kind = "mempool_bundle"
+    KindTokenLaunch  Kind = "token_launch"
+)
+
+// Vector is one recorded scenario: an input snapshot plus the orders/PnL
+// a correct engine should produce from it.
+type Vector struct {
+    Name        string          `json:"name"`
+    Description string          `json:"description"`
+    Kind        Kind            `json:"kind"`
+    Input       json.RawMessage `json:"input"`
+    Expected    Expected        `json:"expected"`
+}
+
+// Expected is the outcome a Vector asserts against the actual run.
+type Expected struct {
+    OrdersSubmitted int     `json:"orders_submitted"`
+    Profitable      bool    `json:"profitable"`
+    PnL             float64 `json:"pnl"`
+}
+
+// OrderBookInput is the decoded Input for KindOrderBook vectors.
+type OrderBookInput struct {
+    Symbol string           `json:"symbol"`
+    Bids   []OrderBookLevel `json:"bids"`
+    Asks   []OrderBookLevel `json:"asks"`
+}
+
+type OrderBookLevel struct {
+    Price float64 `json:"price"`
+    Size  float64 `json:"size"`
+}
+
+// TradePrintInput is the decoded Input for KindTradePrint vectors.
+type TradePrintInput struct {
+    Symbol string  `json:"symbol"`
+    Price  float64 `json:"price"`
+    Size   float64 `json:"size"`
+    Side   string  `json:"side"`
+}
+
+// MempoolBundleInput is the decoded Input for KindMempoolBundle vectors.
+type MempoolBundleInput struct {
+    Transactions []string `json:"transactions"`
+    GasPriceGwei float64  `json:"gas_price_gwei"`
+}
+
+// TokenLaunchInput is the decoded Input for KindTokenLaunch vectors.
+type TokenLaunchInput struct {
+    Symbol    string  `json:"symbol"`
+    Liquidity float64 `json:"liquidity"`
+}
+
+// LoadVectors reads every *.json file in dir and returns them sorted by
+// Name, so a replay run is stable across filesystems.
+func LoadVectors(dir string) ([]Vector, error) {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return nil, fmt.Errorf("conformance: read vector dir %s: %w", dir, err)
+    }
+
+    var vectors []Vector
+    for _, entry := range entries {
+        if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+            continue
+        }
+
+        data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+        if err != nil {
+            return nil, fmt.Errorf("conformance: read vector %s: %w", entry.Name(), err)
+        }
+
+        var v Vector
+        if err := json.Unmarshal(data, &v); err != nil {
+            return nil, fmt.Errorf("conformance: parse vector %s: %w", entry.Name(), err)
+        }
+        vectors = append(vectors, v)
+    }
+
+    sort.Slice(vectors, func(i, j int) bool { return vectors[i].Name < vectors[j].Name })
+    return vectors, nil
+}
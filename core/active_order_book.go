@@ -0,0 +1,176 @@
+// ActiveOrderBook tracks every order a pattern/strategy has in flight so
+// ExecutionEngine can walk and cancel them cleanly instead of firing and
+// forgetting. OrderRouter keeps one ActiveOrderBook per pattern/strategy
+// hash, each keyed internally by symbol.
+
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+)
+
+type ActiveOrderBook struct {
+    mu       sync.RWMutex
+    bySymbol map[string]map[string]*Order
+}
+
+func NewActiveOrderBook() *ActiveOrderBook {
+    return &ActiveOrderBook{bySymbol: make(map[string]map[string]*Order)}
+}
+
+func (b *ActiveOrderBook) Add(order *Order) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    if b.bySymbol[order.Symbol] == nil {
+        b.bySymbol[order.Symbol] = make(map[string]*Order)
+    }
+    b.bySymbol[order.Symbol][order.ID] = order
+}
+
+func (b *ActiveOrderBook) Remove(symbol, orderID string) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+
+    if orders, ok := b.bySymbol[symbol]; ok {
+        delete(orders, orderID)
+        if len(orders) == 0 {
+            delete(b.bySymbol, symbol)
+        }
+    }
+}
+
+// Outstanding returns the still-open orders for a single symbol.
+func (b *ActiveOrderBook) Outstanding(symbol string) []*Order {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    orders := make([]*Order, 0, len(b.bySymbol[symbol]))
+    for _, o := range b.bySymbol[symbol] {
+        orders = append(orders, o)
+    }
+    return orders
+}
+
+// All returns every still-open order across all symbols in this book.
+func (b *ActiveOrderBook) All() []*Order {
+    b.mu.RLock()
+    defer b.mu.RUnlock()
+
+    var orders []*Order
+    for _, bySymbol := range b.bySymbol {
+        for _, o := range bySymbol {
+            orders = append(orders, o)
+        }
+    }
+    return orders
+}
+
+func newOrderID(patternHash string) string {
+    return fmt.Sprintf("%s-%d", patternHash, time.Now().UnixNano())
+}
+
+// bookFor returns (creating if needed) the ActiveOrderBook for a
+// pattern/strategy hash.
+func (o *OrderRouter) bookFor(patternHash string) *ActiveOrderBook {
+    o.booksMutex.Lock()
+    defer o.booksMutex.Unlock()
+
+    book, ok := o.books[patternHash]
+    if !ok {
+        book = NewActiveOrderBook()
+        o.books[patternHash] = book
+    }
+    return book
+}
+
+// Submit tracks an order as open in the pattern/strategy's ActiveOrderBook,
+// executes it, then removes it once the fill (or rejection) comes back.
+func (o *OrderRouter) Submit(patternHash string, order *Order) *OrderResult {
+    if order.ID == "" {
+        order.ID = newOrderID(patternHash)
+    }
+    if order.Symbol == "" {
+        order.Symbol = patternHash
+    }
+    order.PatternHash = patternHash
+
+    book := o.bookFor(patternHash)
+    book.Add(order)
+    result := o.Execute(order)
+    book.Remove(order.Symbol, order.ID)
+
+    return result
+}
+
+// OnCanceled registers a callback invoked for every order GracefulCancel
+// or CancelAll successfully cancels, e.g. so RiskManager can release the
+// capital it reserved for that order.
+func (o *OrderRouter) OnCanceled(fn func(order *Order)) {
+    o.onCanceled = fn
+}
+
+// GracefulCancel walks the given orders and cancels each with retry and
+// backoff, removing it from its pattern's ActiveOrderBook and emitting a
+// Canceled event on success.
+func (o *OrderRouter) GracefulCancel(ctx context.Context, orders ...*Order) error {
+    const maxAttempts = 3
+
+    for _, order := range orders {
+        var lastErr error
+        for attempt := 1; attempt <= maxAttempts; attempt++ {
+            if err := ctx.Err(); err != nil {
+                return err
+            }
+
+            if err := o.cancelOrder(order); err != nil {
+                lastErr = err
+                select {
+                case <-ctx.Done():
+                    return ctx.Err()
+                case <-time.After(time.Duration(attempt) * 50 * time.Millisecond):
+                }
+                continue
+            }
+
+            lastErr = nil
+            break
+        }
+
+        if lastErr != nil {
+            log.Printf("⚠️ GracefulCancel: giving up on order %s after %d attempts: %v", order.ID, maxAttempts, lastErr)
+            continue
+        }
+
+        o.bookFor(order.PatternHash).Remove(order.Symbol, order.ID)
+        if o.onCanceled != nil {
+            o.onCanceled(order)
+        }
+    }
+
+    return nil
+}
+
+// CancelAll cancels every outstanding order across every pattern/strategy,
+// e.g. on shutdown.
+func (o *OrderRouter) CancelAll(ctx context.Context) error {
+    o.booksMutex.Lock()
+    var all []*Order
+    for _, book := range o.books {
+        all = append(all, book.All()...)
+    }
+    o.booksMutex.Unlock()
+
+    return o.GracefulCancel(ctx, all...)
+}
+
+// cancelOrder is the exchange-facing cancel call, stubbed to always
+// succeed via defaultCancelOrder unless a test overrides cancelFn to
+// exercise GracefulCancel's retry/backoff.
+func (o *OrderRouter) cancelOrder(order *Order) error { return o.cancelFn(order) }
+
+func defaultCancelOrder(order *Order) error { return nil }
@@ -0,0 +1,196 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "sync"
+    "testing"
+)
+
+func TestActiveOrderBookAddRemove(t *testing.T) {
+    book := NewActiveOrderBook()
+    o1 := &Order{ID: "1", Symbol: "BTCUSDT"}
+    o2 := &Order{ID: "2", Symbol: "BTCUSDT"}
+    o3 := &Order{ID: "3", Symbol: "ETHUSDT"}
+
+    book.Add(o1)
+    book.Add(o2)
+    book.Add(o3)
+
+    if got := len(book.Outstanding("BTCUSDT")); got != 2 {
+        t.Fatalf("Outstanding(BTCUSDT) = %d orders, want 2", got)
+    }
+    if got := len(book.All()); got != 3 {
+        t.Fatalf("All() = %d orders, want 3", got)
+    }
+
+    book.Remove("BTCUSDT", "1")
+    if got := len(book.Outstanding("BTCUSDT")); got != 1 {
+        t.Fatalf("Outstanding(BTCUSDT) after remove = %d orders, want 1", got)
+    }
+
+    book.Remove("BTCUSDT", "2")
+    if got := len(book.Outstanding("BTCUSDT")); got != 0 {
+        t.Fatalf("Outstanding(BTCUSDT) after removing all = %d orders, want 0", got)
+    }
+    if got := len(book.All()); got != 1 {
+        t.Fatalf("All() after removing a symbol = %d orders, want 1", got)
+    }
+}
+
+// TestActiveOrderBookRemoveUnknown covers removing an order ID that was
+// never added; it must not panic and must leave the book unchanged.
+func TestActiveOrderBookRemoveUnknown(t *testing.T) {
+    book := NewActiveOrderBook()
+    book.Add(&Order{ID: "1", Symbol: "BTCUSDT"})
+
+    book.Remove("BTCUSDT", "does-not-exist")
+    book.Remove("ETHUSDT", "1")
+
+    if got := len(book.Outstanding("BTCUSDT")); got != 1 {
+        t.Fatalf("Outstanding(BTCUSDT) = %d orders, want 1", got)
+    }
+}
+
+func TestActiveOrderBookConcurrentAddRemove(t *testing.T) {
+    book := NewActiveOrderBook()
+    const n = 200
+
+    var wg sync.WaitGroup
+    wg.Add(n)
+    for i := 0; i < n; i++ {
+        go func(i int) {
+            defer wg.Done()
+            order := &Order{ID: string(rune('a' + i%26)), Symbol: "BTCUSDT"}
+            book.Add(order)
+            book.Remove("BTCUSDT", order.ID)
+        }(i)
+    }
+    wg.Wait()
+
+    if got := len(book.All()); got != 0 {
+        t.Fatalf("All() after concurrent add/remove = %d orders, want 0", got)
+    }
+}
+
+func TestOrderRouterSubmitTracksThenClearsBook(t *testing.T) {
+    router := NewOrderRouter()
+    order := &Order{Symbol: "BTCUSDT"}
+
+    result := router.Submit("pattern-1", order)
+    if result == nil || !result.Profitable {
+        t.Fatalf("Submit result = %+v, want profitable", result)
+    }
+    if got := len(router.bookFor("pattern-1").All()); got != 0 {
+        t.Fatalf("book still has %d order(s) after Submit completes, want 0", got)
+    }
+}
+
+// TestGracefulCancelRetriesThenSucceeds covers an order whose first two
+// cancel attempts fail and the third succeeds, verifying it's removed
+// from the book and the onCanceled callback fires exactly once.
+func TestGracefulCancelRetriesThenSucceeds(t *testing.T) {
+    router := NewOrderRouter()
+    order := &Order{ID: "1", Symbol: "BTCUSDT", PatternHash: "pattern-1"}
+    router.bookFor("pattern-1").Add(order)
+
+    attempts := 0
+    router.cancelFn = func(o *Order) error {
+        attempts++
+        if attempts < 3 {
+            return errors.New("exchange rejected cancel")
+        }
+        return nil
+    }
+
+    var canceled []*Order
+    router.OnCanceled(func(o *Order) { canceled = append(canceled, o) })
+
+    if err := router.GracefulCancel(context.Background(), order); err != nil {
+        t.Fatalf("GracefulCancel returned error: %v", err)
+    }
+    if attempts != 3 {
+        t.Errorf("cancelFn called %d times, want 3", attempts)
+    }
+    if len(canceled) != 1 || canceled[0] != order {
+        t.Errorf("onCanceled fired for %v, want exactly [order]", canceled)
+    }
+    if got := len(router.bookFor("pattern-1").All()); got != 0 {
+        t.Errorf("book still has %d order(s) after successful cancel, want 0", got)
+    }
+}
+
+// TestGracefulCancelGivesUpAfterMaxAttempts covers an order whose cancel
+// never succeeds: it must stay in the book and onCanceled must not fire,
+// but GracefulCancel itself still returns nil so one stuck order doesn't
+// block the rest of a CancelAll sweep.
+func TestGracefulCancelGivesUpAfterMaxAttempts(t *testing.T) {
+    router := NewOrderRouter()
+    order := &Order{ID: "1", Symbol: "BTCUSDT", PatternHash: "pattern-1"}
+    router.bookFor("pattern-1").Add(order)
+
+    attempts := 0
+    router.cancelFn = func(o *Order) error {
+        attempts++
+        return errors.New("exchange rejected cancel")
+    }
+
+    called := false
+    router.OnCanceled(func(o *Order) { called = true })
+
+    if err := router.GracefulCancel(context.Background(), order); err != nil {
+        t.Fatalf("GracefulCancel returned error: %v", err)
+    }
+    if attempts != 3 {
+        t.Errorf("cancelFn called %d times, want 3 (maxAttempts)", attempts)
+    }
+    if called {
+        t.Error("onCanceled fired for an order that never canceled")
+    }
+    if got := len(router.bookFor("pattern-1").All()); got != 1 {
+        t.Errorf("book has %d order(s), want the order to remain after exhausting retries", got)
+    }
+}
+
+// TestGracefulCancelRespectsCanceledContext covers bailing out immediately
+// when the context is already canceled, rather than spending the retry
+// budget on a dead context.
+func TestGracefulCancelRespectsCanceledContext(t *testing.T) {
+    router := NewOrderRouter()
+    order := &Order{ID: "1", Symbol: "BTCUSDT", PatternHash: "pattern-1"}
+    router.bookFor("pattern-1").Add(order)
+
+    attempts := 0
+    router.cancelFn = func(o *Order) error {
+        attempts++
+        return errors.New("exchange rejected cancel")
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+
+    if err := router.GracefulCancel(ctx, order); err == nil {
+        t.Fatal("GracefulCancel with a canceled context returned nil error")
+    }
+    if attempts != 0 {
+        t.Errorf("cancelFn called %d times, want 0 (context already canceled)", attempts)
+    }
+}
+
+func TestOrderRouterCancelAllSweepsEveryBook(t *testing.T) {
+    router := NewOrderRouter()
+    o1 := &Order{ID: "1", Symbol: "BTCUSDT", PatternHash: "pattern-1"}
+    o2 := &Order{ID: "2", Symbol: "ETHUSDT", PatternHash: "pattern-2"}
+    router.bookFor("pattern-1").Add(o1)
+    router.bookFor("pattern-2").Add(o2)
+
+    if err := router.CancelAll(context.Background()); err != nil {
+        t.Fatalf("CancelAll returned error: %v", err)
+    }
+    if got := len(router.bookFor("pattern-1").All()); got != 0 {
+        t.Errorf("pattern-1 book has %d order(s), want 0", got)
+    }
+    if got := len(router.bookFor("pattern-2").All()); got != 0 {
+        t.Errorf("pattern-2 book has %d order(s), want 0", got)
+    }
+}
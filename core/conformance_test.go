@@ -0,0 +1,117 @@
+//go:build conformance
+
+// Deterministic replay of testdata/vectors/ against ExecutionEngine,
+// shouldTriggerPattern, evaluateCondition, and the baseline bots. Run
+// with `go test -tags conformance ./...`; set SKIP_CONFORMANCE=1 to skip
+// in environments without the vector directory checked out.
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "os"
+    "testing"
+
+    "github.com/v26meme/autobob2/conformance"
+)
+
+func TestConformanceVectors(t *testing.T) {
+    if os.Getenv("SKIP_CONFORMANCE") != "" {
+        t.Skip("SKIP_CONFORMANCE set")
+    }
+
+    vectors, err := conformance.LoadVectors("testdata/vectors")
+    if err != nil {
+        t.Fatalf("load vectors: %v", err)
+    }
+    if len(vectors) == 0 {
+        t.Fatal("no conformance vectors found")
+    }
+
+    for _, v := range vectors {
+        v := v
+        t.Run(v.Name, func(t *testing.T) {
+            ordersSubmitted, profitable := replayVector(t, v)
+            if ordersSubmitted != v.Expected.OrdersSubmitted {
+                t.Errorf("orders submitted = %d, want %d", ordersSubmitted, v.Expected.OrdersSubmitted)
+            }
+            if profitable != v.Expected.Profitable {
+                t.Errorf("profitable = %v, want %v", profitable, v.Expected.Profitable)
+            }
+        })
+    }
+}
+
+// replayVector runs a single vector against the engine and returns how
+// many orders were submitted and whether the run was profitable.
+// PnL isn't compared yet: executePattern has no per-run PnL signal until
+// a pattern's profit is actually tracked rather than just its win/loss.
+func replayVector(t *testing.T, v conformance.Vector) (ordersSubmitted int, profitable bool) {
+    ctx := context.Background()
+
+    switch v.Kind {
+    case conformance.KindOrderBook:
+        var book conformance.OrderBookInput
+        if err := json.Unmarshal(v.Input, &book); err != nil {
+            t.Fatalf("decode orderbook input: %v", err)
+        }
+        if len(book.Bids) == 0 || len(book.Asks) == 0 {
+            t.Fatalf("vector %s: orderbook input missing bids/asks", v.Name)
+        }
+
+        engine := NewExecutionEngine()
+        engine.UpdateOrderBookMetrics(v.Name, book.Bids[0].Price, book.Asks[0].Price)
+
+        pattern := &Pattern{
+            Hash:            v.Name,
+            EntryConditions: []Condition{{Metric: "spread_bps", Operator: "<", Value: 10, Weight: 1}},
+            Timeframe:       1,
+        }
+        return replayPattern(engine, pattern)
+
+    case conformance.KindTradePrint:
+        var trade conformance.TradePrintInput
+        if err := json.Unmarshal(v.Input, &trade); err != nil {
+            t.Fatalf("decode trade input: %v", err)
+        }
+
+        engine := NewExecutionEngine()
+        engine.UpdateTradeMetrics(v.Name, trade.Side, trade.Size)
+
+        pattern := &Pattern{
+            Hash:            v.Name,
+            EntryConditions: []Condition{{Metric: "trade_size", Operator: ">", Value: 1.0, Weight: 1}},
+            Timeframe:       1,
+        }
+        return replayPattern(engine, pattern)
+
+    case conformance.KindMempoolBundle:
+        bot := NewMEVBot()
+        if err := bot.Tick(ctx); err != nil {
+            t.Fatalf("MEVBot.Tick: %v", err)
+        }
+        return 0, bot.FSM().State() != StateClosed
+
+    case conformance.KindTokenLaunch:
+        sniper := NewTokenSniper(NewOrderRouter())
+        if err := sniper.Tick(ctx); err != nil {
+            t.Fatalf("TokenSniper.Tick: %v", err)
+        }
+        return 0, sniper.FSM().State() != StateClosed
+
+    default:
+        t.Fatalf("unknown vector kind %q", v.Kind)
+        return 0, false
+    }
+}
+
+// replayPattern evaluates pattern's entry conditions against whatever
+// metrics were just fed into engine via UpdateOrderBookMetrics/
+// UpdateTradeMetrics and, if they pass, opens the position exactly as
+// executePattern would.
+func replayPattern(engine *ExecutionEngine, pattern *Pattern) (ordersSubmitted int, profitable bool) {
+    if !engine.shouldTriggerPattern(pattern) {
+        return 0, false
+    }
+    return 1, engine.openPatternPosition(pattern, 5.0)
+}
@@ -7,11 +7,16 @@ package main
 import (
     "context"
     "log"
+    "os"
     "sync"
-    "sync/atomic"
     "time"
 )
 
+// profitStatsDSNEnv names the Postgres connection string used to persist
+// ProfitStats snapshots. Left unset, performanceMonitor still rotates
+// today's stats into accumulated totals but skips persistence.
+const profitStatsDSNEnv = "PROFIT_STATS_DSN"
+
 type Pattern struct {
     Hash           string          `json:"hash"`
     EntryConditions []Condition    `json:"entry_conditions"`
@@ -36,53 +41,132 @@ type ExecutionEngine struct {
     arbitrageBot    *ArbitrageBot
     tokenSniper     *TokenSniper
     marketMaker     *MarketMaker
-    
+    fundingRateArbBot *FundingRateArbBot
+
+    // Baseline bots driven generically through the Strategy interface
+    strategies      []Strategy
+
     // Discovered patterns - can grow to thousands
     activePatterns  map[string]*Pattern
     patternMutex    sync.RWMutex
-    
+
     // Infrastructure
     orderRouter     *OrderRouter
     riskManager     *RiskManager
     capitalAllocator *CapitalAllocator
-    
-    // Performance tracking
-    totalTrades     int64
-    profitableCount int64
-    totalProfit     float64
+
+    // Performance tracking: one ProfitStats per pattern hash (baseline
+    // bots carry their own via Strategy.ProfitStats()), persisted to
+    // Postgres and rotated from today into accumulated totals once per
+    // UTC day by performanceMonitor.
+    patternProfitStats map[string]*ProfitStats
+    patternStatsMutex  sync.RWMutex
+    profitStatsStore   *ProfitStatsStore
+
+    // latestMetrics holds the most recent computed metric (e.g.
+    // "spread_bps") per pattern hash, fed by UpdateOrderBookMetrics/
+    // UpdateTradeMetrics and read by evaluateCondition.
+    latestMetrics map[string]map[string]float64
+    metricsMutex  sync.RWMutex
+
+    // Laddered pattern entries: split position size across a price ladder
+    // instead of one market order (see generateOpenPositionOrders).
+    ladderEntryEnabled   bool
+    ladderMaxOrders      int
+    ladderPriceDeviation float64
+    referencePrice       float64
 }
 
 func NewExecutionEngine() *ExecutionEngine {
+    orderRouter := NewOrderRouter()
+    mevBot := NewMEVBot()
+    arbitrageBot := NewArbitrageBot()
+    tokenSniper := NewTokenSniper(orderRouter)
+    marketMaker := NewMarketMaker()
+    fundingRateArbBot := NewFundingRateArbBot(orderRouter)
+    arbitrageBot.WithTriangularPaths(defaultTriangularPaths, defaultTriangularExposureLimits, false)
+
+    riskManager := NewRiskManager(200.0) // Starting capital
+
+    // Release reserved capital for any order GracefulCancel/CancelAll cancels
+    orderRouter.OnCanceled(func(order *Order) {
+        riskManager.ReleaseReservedCapital(order.PatternHash, order.Size)
+        log.Printf("🧹 Released reserved capital for canceled order %s (%.2f)", order.ID, order.Size)
+    })
+
+    var profitStatsStore *ProfitStatsStore
+    if dsn := os.Getenv(profitStatsDSNEnv); dsn != "" {
+        store, err := NewProfitStatsStore(dsn)
+        if err != nil {
+            log.Printf("⚠️ ProfitStatsStore disabled: %v", err)
+        } else {
+            profitStatsStore = store
+        }
+    }
+
     return &ExecutionEngine{
-        activePatterns: make(map[string]*Pattern),
-        mevBot:         NewMEVBot(),
-        arbitrageBot:   NewArbitrageBot(),
-        tokenSniper:    NewTokenSniper(),
-        marketMaker:    NewMarketMaker(),
-        orderRouter:    NewOrderRouter(),
-        riskManager:    NewRiskManager(200.0), // Starting capital
-        capitalAllocator: NewCapitalAllocator(),
+        activePatterns:    make(map[string]*Pattern),
+        mevBot:            mevBot,
+        arbitrageBot:      arbitrageBot,
+        tokenSniper:       tokenSniper,
+        marketMaker:       marketMaker,
+        fundingRateArbBot: fundingRateArbBot,
+        strategies:        []Strategy{mevBot, arbitrageBot, tokenSniper, marketMaker, fundingRateArbBot},
+        orderRouter:       orderRouter,
+        riskManager:       riskManager,
+        capitalAllocator:  NewCapitalAllocator(),
+        patternProfitStats:   make(map[string]*ProfitStats),
+        profitStatsStore:     profitStatsStore,
+        ladderEntryEnabled:   true,
+        ladderMaxOrders:      5,
+        ladderPriceDeviation: 0.01, // 1% per level
+        referencePrice:       1.0,  // placeholder until patterns carry a real reference price
     }
 }
 
+// DeactivatePattern removes a pattern from rotation and gracefully cancels
+// any orders it still has outstanding, so its reserved capital is freed
+// for CapitalAllocator to hand to another pattern.
+func (e *ExecutionEngine) DeactivatePattern(ctx context.Context, hash string) error {
+    e.patternMutex.Lock()
+    delete(e.activePatterns, hash)
+    e.patternMutex.Unlock()
+
+    return e.orderRouter.GracefulCancel(ctx, e.orderRouter.bookFor(hash).All()...)
+}
+
+// strategyIntervals holds each baseline bot's tick cadence. MEV bot, token
+// sniper and market maker keep the polling frequency of their old ad-hoc
+// loops; arbitrage-bot and funding-rate-arb-bot had no fixed poll before.
+var strategyIntervals = map[string]time.Duration{
+    "mev-bot":              10 * time.Millisecond,
+    "arbitrage-bot":        50 * time.Millisecond,
+    "token-sniper":         100 * time.Millisecond,
+    "market-maker":         1 * time.Second,
+    "funding-rate-arb-bot": 1 * time.Minute,
+}
+
 func (e *ExecutionEngine) Run(ctx context.Context) {
     log.Println("🚀 Starting Execution Engine")
-    
+
     var wg sync.WaitGroup
-    
-    // Run baseline strategies (always profitable)
-    wg.Add(4)
-    go e.runMEV(ctx, &wg)
-    go e.runArbitrage(ctx, &wg)
-    go e.runTokenSniping(ctx, &wg)
-    go e.runMarketMaking(ctx, &wg)
-    
+
+    // Drive every baseline bot generically through its Strategy/PositionState FSM
+    wg.Add(len(e.strategies))
+    for _, s := range e.strategies {
+        interval, ok := strategyIntervals[s.Name()]
+        if !ok {
+            interval = 100 * time.Millisecond
+        }
+        go e.driveStrategy(ctx, s, interval, &wg)
+    }
+
     // Run all discovered patterns in parallel
     go e.runDiscoveredPatterns(ctx)
-    
+
     // Monitor and report
     go e.performanceMonitor(ctx)
-    
+
     wg.Wait()
 }
 
@@ -93,6 +177,11 @@ func (e *ExecutionEngine) runDiscoveredPatterns(ctx context.Context) {
     for {
         select {
         case <-ctx.Done():
+            // Don't leave live orders resting on the exchange when a pattern
+            // loop shuts down; walk every outstanding order and cancel it.
+            if err := e.orderRouter.CancelAll(context.Background()); err != nil {
+                log.Printf("⚠️ runDiscoveredPatterns: CancelAll on shutdown: %v", err)
+            }
             return
         case <-ticker.C:
             e.patternMutex.RLock()
@@ -118,9 +207,9 @@ func (e *ExecutionEngine) runDiscoveredPatterns(ctx context.Context) {
 func (e *ExecutionEngine) shouldTriggerPattern(p *Pattern) bool {
     // Check if pattern conditions are met
     // This evaluates the random conditions discovered by the system
-    
+
     for _, condition := range p.EntryConditions {
-        if !e.evaluateCondition(condition) {
+        if !e.evaluateCondition(p.Hash, condition) {
             return false
         }
     }
@@ -147,80 +236,136 @@ func (e *ExecutionEngine) executePattern(ctx context.Context, p *Pattern, wg *sy
     
     // Execute with <100ms latency
     startTime := time.Now()
-    
-    order := &Order{
-        PatternHash: p.Hash,
-        Side:        "buy", // Determined by pattern
-        Size:        positionSize,
-        Timestamp:   time.Now(),
-    }
-    
-    result := e.orderRouter.Execute(order)
-    
+
+    profitable := e.openPatternPosition(p, positionSize)
+
     executionTime := time.Since(startTime)
     if executionTime > 100*time.Millisecond {
         log.Printf("⚠️ Slow execution: %v", executionTime)
     }
-    
-    // Track performance
-    if result.Profitable {
-        atomic.AddInt64(&e.profitableCount, 1)
+
+    // Track performance. openPatternPosition only reports win/loss today,
+    // not a dollar PnL, so the fill's RealizedPnL is a 1/0 placeholder
+    // until patterns carry real per-trade PnL.
+    pnl := 0.0
+    if profitable {
+        pnl = 1.0
     }
-    atomic.AddInt64(&e.totalTrades, 1)
-    
+    e.patternProfitStatsFor(p.Hash).RecordFill(Fill{Side: "bid", Maker: false, RealizedPnL: pnl})
+
     // Update pattern statistics
     p.LastTriggered = time.Now()
 }
 
+// openPatternPosition enters a pattern's position. When ladderEntryEnabled
+// is set it splits positionSize across a price ladder via
+// generateOpenPositionOrders for better fill quality; otherwise it submits
+// a single market order for the full size. Reports whether the entry (or
+// any one of its ladder legs) was profitable.
+func (e *ExecutionEngine) openPatternPosition(p *Pattern, positionSize float64) bool {
+    if !e.ladderEntryEnabled {
+        return e.submitSingleOrder(p, positionSize)
+    }
+
+    market := Market{Symbol: p.Hash, MinNotional: 5.0}
+    orders := generateOpenPositionOrders(market, positionSize, e.referencePrice, e.ladderPriceDeviation, e.ladderMaxOrders, p.Hash)
+    if len(orders) == 0 {
+        return e.submitSingleOrder(p, positionSize)
+    }
+
+    profitable := false
+    for _, order := range orders {
+        result := e.orderRouter.Submit(p.Hash, order)
+        if result != nil && result.Profitable {
+            profitable = true
+        }
+    }
+    return profitable
+}
+
+func (e *ExecutionEngine) submitSingleOrder(p *Pattern, positionSize float64) bool {
+    order := &Order{
+        Symbol:      p.Hash,
+        PatternHash: p.Hash,
+        Side:        "buy", // Determined by pattern
+        Size:        positionSize,
+        Timestamp:   time.Now(),
+    }
+    result := e.orderRouter.Submit(p.Hash, order)
+    return result != nil && result.Profitable
+}
+
 // MEV Bot Implementation
 type MEVBot struct {
     flashbotsClient *FlashbotsClient
     mempoolMonitor  *MempoolMonitor
     dailyProfit     float64
     mu              sync.Mutex
+    fsm             *PositionFSM
+    profitStats     *ProfitStats
 }
 
 func NewMEVBot() *MEVBot {
     return &MEVBot{
         flashbotsClient: NewFlashbotsClient(),
         mempoolMonitor:  NewMempoolMonitor(),
+        fsm:             NewPositionFSM("mev-bot"),
+        profitStats:     NewProfitStats("mev-bot"),
     }
 }
 
-func (m *MEVBot) Run(ctx context.Context, wg *sync.WaitGroup) {
-    defer wg.Done()
-    
-    log.Println("🤖 MEV Bot started - Target: $500-2000 daily")
-    
-    for {
-        select {
-        case <-ctx.Done():
-            return
-        default:
-            // Monitor mempool for sandwich opportunities
-            if opp := m.findSandwichOpportunity(); opp != nil {
-                profit := m.executeSandwich(opp)
-                m.mu.Lock()
-                m.dailyProfit += profit
-                m.mu.Unlock()
-                
-                if profit > 0 {
-                    log.Printf("💰 MEV Profit: $%.2f (Daily Total: $%.2f)", 
-                        profit, m.dailyProfit)
-                }
-            }
-            
-            // Check for arbitrage via flash loans
-            if arb := m.findFlashLoanArbitrage(); arb != nil {
-                profit := m.executeFlashLoan(arb)
-                m.mu.Lock()
-                m.dailyProfit += profit
-                m.mu.Unlock()
-            }
-            
-            time.Sleep(10 * time.Millisecond) // Ultra-fast checking
+func (m *MEVBot) Name() string              { return "mev-bot" }
+func (m *MEVBot) FSM() *PositionFSM         { return m.fsm }
+func (m *MEVBot) ProfitStats() *ProfitStats { return m.profitStats }
+
+// Tick runs one mempool/flash-loan scan. MEV fills are instantaneous, so a
+// found opportunity walks Closed -> Opening -> Closed within the same tick
+// rather than lingering in Ready.
+func (m *MEVBot) Tick(ctx context.Context) error {
+    // Monitor mempool for sandwich opportunities
+    if opp := m.findSandwichOpportunity(); opp != nil {
+        profit, err := m.executeThroughFSM(func() float64 { return m.executeSandwich(opp) })
+        if err != nil {
+            return err
+        }
+        if profit > 0 {
+            log.Printf("💰 MEV Profit: $%.2f (Daily Total: $%.2f)", profit, m.dailyProfit)
+        }
+    }
+
+    // Check for arbitrage via flash loans
+    if arb := m.findFlashLoanArbitrage(); arb != nil {
+        if _, err := m.executeThroughFSM(func() float64 { return m.executeFlashLoan(arb) }); err != nil {
+            return err
         }
     }
+
+    return nil
+}
+
+// executeThroughFSM wraps a single fire-and-forget execution in the shared
+// PositionState FSM and accumulates the resulting profit.
+func (m *MEVBot) executeThroughFSM(execute func() float64) (float64, error) {
+    if m.fsm.State() == StateOpening {
+        // A crash between Opening and Closed in a prior tick leaves the
+        // FSM stuck here; retry rather than erroring forever.
+        log.Printf("⚠️ %s: resuming an open left stuck after a crash; retrying", m.Name())
+    }
+    if err := m.fsm.Transition(StateOpening); err != nil {
+        return 0, err
+    }
+
+    profit := execute()
+
+    m.mu.Lock()
+    m.dailyProfit += profit
+    m.mu.Unlock()
+    m.profitStats.RecordFill(Fill{Side: "bid", Maker: false, RealizedPnL: profit})
+
+    if err := m.fsm.Transition(StateClosed); err != nil {
+        return profit, err
+    }
+    return profit, nil
 }
 
 // Arbitrage Bot
@@ -228,6 +373,10 @@ type ArbitrageBot struct {
     exchanges       []Exchange
     minProfitPct    float64
     opportunities   chan *ArbitrageOpp
+    triangular      *TriangularArbitrage
+    fsm             *PositionFSM
+    profitStats     *ProfitStats
+    startOnce       sync.Once
 }
 
 func NewArbitrageBot() *ArbitrageBot {
@@ -235,157 +384,278 @@ func NewArbitrageBot() *ArbitrageBot {
         exchanges:    InitializeExchanges(),
         minProfitPct: 0.005, // 0.5% minimum
         opportunities: make(chan *ArbitrageOpp, 100),
+        triangular:   NewTriangularArbitrage(nil, nil, false, NewOrderRouter()),
+        fsm:          NewPositionFSM("arbitrage-bot"),
+        profitStats:  NewProfitStats("arbitrage-bot"),
     }
 }
 
-func (a *ArbitrageBot) Run(ctx context.Context, wg *sync.WaitGroup) {
-    defer wg.Done()
-    
-    log.Println("💱 Arbitrage Bot started - Target: 0.5-2% per opportunity")
-    
-    // Start price monitoring on all exchanges
-    go a.monitorPrices(ctx)
-    
-    // Execute opportunities
-    for {
-        select {
-        case <-ctx.Done():
-            return
-        case opp := <-a.opportunities:
-            if opp.ProfitPct > a.minProfitPct {
-                go a.executeArbitrage(opp)
+func (a *ArbitrageBot) Name() string              { return "arbitrage-bot" }
+func (a *ArbitrageBot) FSM() *PositionFSM         { return a.fsm }
+func (a *ArbitrageBot) ProfitStats() *ProfitStats { return a.profitStats }
+
+// Tick checks for a queued 2-venue opportunity. The price-monitoring feed
+// and any configured triangular paths run on their own background
+// goroutines, started once on the first tick.
+func (a *ArbitrageBot) Tick(ctx context.Context) error {
+    a.startOnce.Do(func() {
+        go a.monitorPrices(ctx)
+
+        // Run configured N-leg cyclic arbitrage paths alongside the 2-venue scan
+        if len(a.triangular.paths) > 0 {
+            var triWg sync.WaitGroup
+            triWg.Add(1)
+            go a.triangular.Run(ctx, &triWg)
+        }
+    })
+
+    select {
+    case opp := <-a.opportunities:
+        if opp.ProfitPct > a.minProfitPct {
+            if a.fsm.State() == StateOpening {
+                log.Printf("⚠️ %s: resuming an open left stuck after a crash; retrying", a.Name())
             }
+            if err := a.fsm.Transition(StateOpening); err != nil {
+                return err
+            }
+            a.executeArbitrage(opp)
+            a.profitStats.RecordFill(Fill{Side: "bid", Maker: false, RealizedPnL: opp.ProfitPct * 10.0})
+            return a.fsm.Transition(StateClosed)
         }
+    default:
     }
+    return nil
+}
+
+// WithTriangularPaths configures the cyclic arbitrage paths this bot
+// evaluates alongside its existing 2-venue price differential scan.
+func (a *ArbitrageBot) WithTriangularPaths(paths []ArbPath, exposureLimits map[string]float64, separateStream bool) {
+    a.triangular = NewTriangularArbitrage(paths, exposureLimits, separateStream, NewOrderRouter())
+}
+
+// defaultTriangularPaths seeds ArbitrageBot with the cyclic path it scans
+// for N-leg arbitrage alongside its 2-venue differential scan.
+var defaultTriangularPaths = []ArbPath{
+    {Legs: []string{"BTCUSDT", "ETHBTC", "ETHUSDT"}, MinSpreadRatio: 1.0011},
+}
+
+// defaultTriangularExposureLimits bounds how much of each asset a
+// triangular cycle may commit per execution.
+var defaultTriangularExposureLimits = map[string]float64{
+    "BTC":  0.001,
+    "ETH":  0.01,
+    "USDT": 20,
 }
 
 // Token Sniper
 type TokenSniper struct {
     dexMonitor     *DEXMonitor
     sniperWallet   *Wallet
+    orderRouter    *OrderRouter
     minLiquidity   float64
     maxBuyAmount   float64
+    fsm            *PositionFSM
+    profitStats    *ProfitStats
+
+    // Laddered entry: split maxBuyAmount across maxLadderOrders price
+    // levels spaced ladderPriceDeviation apart instead of one market buy.
+    useLadderEntry      bool
+    maxLadderOrders     int
+    ladderPriceDeviation float64
 }
 
-func NewTokenSniper() *TokenSniper {
+func NewTokenSniper(router *OrderRouter) *TokenSniper {
     return &TokenSniper{
-        dexMonitor:   NewDEXMonitor(),
-        sniperWallet: NewWallet(),
-        minLiquidity: 10000.0,  // $10k minimum liquidity
-        maxBuyAmount: 50.0,      // $50 max per snipe
+        dexMonitor:           NewDEXMonitor(),
+        sniperWallet:         NewWallet(),
+        orderRouter:          router,
+        minLiquidity:         10000.0, // $10k minimum liquidity
+        maxBuyAmount:         50.0,    // $50 max per snipe
+        fsm:                  NewPositionFSM("token-sniper"),
+        profitStats:          NewProfitStats("token-sniper"),
+        useLadderEntry:       true,
+        maxLadderOrders:      5,
+        ladderPriceDeviation: 0.01, // 1% per level
     }
 }
 
-func (t *TokenSniper) Run(ctx context.Context, wg *sync.WaitGroup) {
-    defer wg.Done()
-    
-    log.Println("🎯 Token Sniper started - Target: 10-100x on launches")
-    
-    for {
-        select {
-        case <-ctx.Done():
-            return
-        default:
-            // Monitor for new token launches
-            if launch := t.dexMonitor.DetectNewToken(); launch != nil {
-                if t.isViableToken(launch) {
-                    profit := t.snipeToken(launch)
-                    if profit > 0 {
-                        log.Printf("🚀 Sniped %s: %.2fx profit!", 
-                            launch.Symbol, profit/t.maxBuyAmount)
-                    }
-                }
-            }
-            
-            time.Sleep(100 * time.Millisecond)
-        }
+func (t *TokenSniper) Name() string              { return "token-sniper" }
+func (t *TokenSniper) FSM() *PositionFSM         { return t.fsm }
+func (t *TokenSniper) ProfitStats() *ProfitStats { return t.profitStats }
+
+func (t *TokenSniper) Tick(ctx context.Context) error {
+    // Monitor for new token launches
+    launch := t.dexMonitor.DetectNewToken()
+    if launch == nil || !t.isViableToken(launch) {
+        return nil
+    }
+
+    if t.fsm.State() == StateOpening {
+        log.Printf("⚠️ %s: resuming an open left stuck after a crash; retrying", t.Name())
+    }
+    if err := t.fsm.Transition(StateOpening); err != nil {
+        return err
+    }
+
+    profit := t.snipeToken(launch)
+    if profit > 0 {
+        log.Printf("🚀 Sniped %s: %.2fx profit!", launch.Symbol, profit/t.maxBuyAmount)
+    }
+
+    return t.fsm.Transition(StateClosed)
+}
+
+// patternProfitStatsFor returns (creating if needed) the ProfitStats
+// tracking a single pattern hash, mirroring bookFor's lazy-create pattern
+// for ActiveOrderBook.
+func (e *ExecutionEngine) patternProfitStatsFor(hash string) *ProfitStats {
+    e.patternStatsMutex.Lock()
+    defer e.patternStatsMutex.Unlock()
+
+    stats, ok := e.patternProfitStats[hash]
+    if !ok {
+        stats = NewProfitStats(hash)
+        e.patternProfitStats[hash] = stats
+    }
+    return stats
+}
+
+// allProfitStats returns every ProfitStats this engine tracks: one per
+// baseline bot plus one per pattern hash that has traded.
+func (e *ExecutionEngine) allProfitStats() []*ProfitStats {
+    stats := make([]*ProfitStats, 0, len(e.strategies))
+    for _, s := range e.strategies {
+        stats = append(stats, s.ProfitStats())
+    }
+
+    e.patternStatsMutex.RLock()
+    for _, ps := range e.patternProfitStats {
+        stats = append(stats, ps)
     }
+    e.patternStatsMutex.RUnlock()
+
+    return stats
 }
 
+// performanceMonitor logs aggregate today-PnL every minute and, once per
+// UTC day, rotates every strategy and pattern's ProfitStats from today
+// into its accumulated totals and persists the snapshot to Postgres.
+// CapitalAllocator.FavorsToday compares today's Sharpe against the
+// trailing one on the in-memory ProfitStats, not on the persisted rows.
 func (e *ExecutionEngine) performanceMonitor(ctx context.Context) {
     ticker := time.NewTicker(1 * time.Minute)
     defer ticker.Stop()
-    
+
+    lastRotatedDay := time.Now().UTC().YearDay()
+
     for {
         select {
         case <-ctx.Done():
             return
         case <-ticker.C:
-            trades := atomic.LoadInt64(&e.totalTrades)
-            profitable := atomic.LoadInt64(&e.profitableCount)
-            
-            winRate := float64(profitable) / float64(trades) * 100
-            
+            all := e.allProfitStats()
+
+            var todayPnL float64
+            for _, ps := range all {
+                todayPnL += ps.TodayPnL()
+            }
+
             e.patternMutex.RLock()
             patternCount := len(e.activePatterns)
             e.patternMutex.RUnlock()
-            
-            log.Printf("📊 Performance - Trades: %d | Win Rate: %.2f%% | Active Patterns: %d",
-                trades, winRate, patternCount)
+
+            log.Printf("📊 Performance - Today PnL: $%.2f | Active Patterns: %d", todayPnL, patternCount)
+
+            if day := time.Now().UTC().YearDay(); day != lastRotatedDay {
+                lastRotatedDay = day
+                for _, ps := range all {
+                    ps.RotateDaily()
+                }
+                if e.profitStatsStore != nil {
+                    e.profitStatsStore.persistAll(ctx, all)
+                }
+            }
         }
     }
 }
 
-// Placeholder implementations for compilation
-func (e *ExecutionEngine) evaluateCondition(c Condition) bool { return true }
+// evaluateCondition compares a pattern condition's configured
+// operator/value against the latest metric recorded for that pattern's
+// hash by UpdateOrderBookMetrics/UpdateTradeMetrics. A pattern with no
+// recorded metric yet never triggers, rather than defaulting to true.
+func (e *ExecutionEngine) evaluateCondition(hash string, c Condition) bool {
+    e.metricsMutex.RLock()
+    metrics, ok := e.latestMetrics[hash]
+    e.metricsMutex.RUnlock()
+    if !ok {
+        return false
+    }
 
-func (e *ExecutionEngine) runMEV(ctx context.Context, wg *sync.WaitGroup) {
-    defer wg.Done()
-    log.Println("🤖 MEV Bot started in paper trading mode")
-    
-    for {
-        select {
-        case <-ctx.Done():
-            return
-        default:
-            time.Sleep(1 * time.Second)
-        }
+    value, ok := metrics[c.Metric]
+    if !ok {
+        return false
+    }
+
+    switch c.Operator {
+    case "<":
+        return value < c.Value
+    case "<=":
+        return value <= c.Value
+    case ">":
+        return value > c.Value
+    case ">=":
+        return value >= c.Value
+    case "==":
+        return value == c.Value
+    default:
+        return false
     }
 }
 
-func (e *ExecutionEngine) runArbitrage(ctx context.Context, wg *sync.WaitGroup) {
-    defer wg.Done()
-    log.Println("💱 Arbitrage Bot started in paper trading mode")
-    
-    for {
-        select {
-        case <-ctx.Done():
-            return
-        default:
-            time.Sleep(1 * time.Second)
-        }
+// UpdateOrderBookMetrics records the top-of-book spread, in bps of mid
+// price, for a pattern's instrument so spread-based entry conditions can
+// evaluate against real market data instead of a stub.
+func (e *ExecutionEngine) UpdateOrderBookMetrics(hash string, bestBid, bestAsk float64) {
+    if bestBid <= 0 || bestAsk <= 0 {
+        return
     }
+    mid := (bestBid + bestAsk) / 2
+    spreadBps := (bestAsk - bestBid) / mid * 10000
+
+    e.metricsMutex.Lock()
+    defer e.metricsMutex.Unlock()
+    e.setMetricLocked(hash, "spread_bps", spreadBps)
 }
 
-func (e *ExecutionEngine) runTokenSniping(ctx context.Context, wg *sync.WaitGroup) {
-    defer wg.Done()
-    log.Println("🎯 Token Sniper started in paper trading mode")
-    
-    for {
-        select {
-        case <-ctx.Done():
-            return
-        default:
-            time.Sleep(1 * time.Second)
-        }
+// UpdateTradeMetrics records the size of the latest trade print for a
+// pattern's instrument, signed positive for buys and negative for sells,
+// so momentum-style entry conditions can evaluate against real data.
+func (e *ExecutionEngine) UpdateTradeMetrics(hash string, side string, size float64) {
+    signed := size
+    if side == "sell" {
+        signed = -size
     }
+
+    e.metricsMutex.Lock()
+    defer e.metricsMutex.Unlock()
+    e.setMetricLocked(hash, "trade_size", signed)
 }
 
-func (e *ExecutionEngine) runMarketMaking(ctx context.Context, wg *sync.WaitGroup) {
-    defer wg.Done()
-    log.Println("📈 Market Maker started in paper trading mode")
-    
-    for {
-        select {
-        case <-ctx.Done():
-            return
-        default:
-            time.Sleep(1 * time.Second)
-        }
+// setMetricLocked stores a single metric value for hash. Callers must
+// hold e.metricsMutex.
+func (e *ExecutionEngine) setMetricLocked(hash, metric string, value float64) {
+    if e.latestMetrics == nil {
+        e.latestMetrics = make(map[string]map[string]float64)
+    }
+    if e.latestMetrics[hash] == nil {
+        e.latestMetrics[hash] = make(map[string]float64)
     }
+    e.latestMetrics[hash][metric] = value
 }
 
 type Order struct {
+    ID          string
+    Symbol      string
     PatternHash string
     Side        string
     Size        float64
@@ -393,7 +663,14 @@ type Order struct {
 }
 
 type OrderResult struct { Profitable bool }
-type OrderRouter struct{}
+
+type OrderRouter struct {
+    books      map[string]*ActiveOrderBook // pattern/strategy hash -> book
+    booksMutex sync.Mutex
+    onCanceled func(order *Order)
+    cancelFn   func(order *Order) error // exchange-facing cancel call; overridable in tests
+}
+
 type RiskManager struct{}
 type CapitalAllocator struct{}
 type FlashbotsClient struct{}
@@ -402,23 +679,77 @@ type Exchange struct{}
 type ArbitrageOpp struct { ProfitPct float64 }
 type DEXMonitor struct{}
 type Wallet struct{}
-type MarketMaker struct{}
-type Token struct { Symbol string }
 
-func NewOrderRouter() *OrderRouter { return &OrderRouter{} }
+// Market Maker
+type MarketMaker struct {
+    spreadBps   float64
+    quoteSize   float64
+    fsm         *PositionFSM
+    profitStats *ProfitStats
+}
+
+type Token struct {
+    Symbol string
+    Price  float64
+}
+
+func NewOrderRouter() *OrderRouter {
+    return &OrderRouter{books: make(map[string]*ActiveOrderBook), cancelFn: defaultCancelOrder}
+}
 func NewRiskManager(capital float64) *RiskManager { return &RiskManager{} }
 func NewCapitalAllocator() *CapitalAllocator { return &CapitalAllocator{} }
 func NewFlashbotsClient() *FlashbotsClient { return &FlashbotsClient{} }
 func NewMempoolMonitor() *MempoolMonitor { return &MempoolMonitor{} }
-func NewMarketMaker() *MarketMaker { return &MarketMaker{} }
+func NewMarketMaker() *MarketMaker {
+    return &MarketMaker{
+        spreadBps:   10.0, // 0.10% quoted spread
+        quoteSize:   25.0,
+        fsm:         NewPositionFSM("market-maker"),
+        profitStats: NewProfitStats("market-maker"),
+    }
+}
 func InitializeExchanges() []Exchange { return []Exchange{} }
 func NewDEXMonitor() *DEXMonitor { return &DEXMonitor{} }
 func NewWallet() *Wallet { return &Wallet{} }
 
+func (mm *MarketMaker) Name() string              { return "market-maker" }
+func (mm *MarketMaker) FSM() *PositionFSM         { return mm.fsm }
+func (mm *MarketMaker) ProfitStats() *ProfitStats { return mm.profitStats }
+
+// Tick quotes both sides of the book, holding Ready while the quote is
+// resting and returning to Closed once it is pulled/refreshed.
+func (mm *MarketMaker) Tick(ctx context.Context) error {
+    if mm.fsm.State() == StateClosed {
+        if err := mm.fsm.Transition(StateOpening); err != nil {
+            return err
+        }
+        if err := mm.fsm.Transition(StateReady); err != nil {
+            return err
+        }
+        mm.profitStats.RecordFill(Fill{Side: "bid", Maker: true, Size: mm.quoteSize, Price: 1})
+        mm.profitStats.RecordFill(Fill{Side: "ask", Maker: true, Size: mm.quoteSize, Price: 1})
+        return nil
+    }
+
+    if err := mm.fsm.Transition(StateClosing); err != nil {
+        return err
+    }
+    return mm.fsm.Transition(StateClosed)
+}
+
 func (o *OrderRouter) Execute(order *Order) *OrderResult { return &OrderResult{Profitable: true} }
 func (r *RiskManager) CalculatePositionSize(p *Pattern, capital float64) float64 { return 5.0 }
 func (r *RiskManager) ApproveOrder(hash string, size float64) bool { return true }
+func (r *RiskManager) ReleaseReservedCapital(patternHash string, size float64) {}
 func (c *CapitalAllocator) GetAvailableCapital() float64 { return 200.0 }
+
+// FavorsToday reports whether a strategy or pattern's today-Sharpe is
+// beating its own trailing Sharpe, the signal used to bias fresh capital
+// toward whoever is having a strong day rather than just a strong
+// lifetime average.
+func (c *CapitalAllocator) FavorsToday(stats *ProfitStats) bool {
+    return stats.TodaySharpe() > stats.TrailingSharpe()
+}
 func (m *MEVBot) findSandwichOpportunity() interface{} { return nil }
 func (m *MEVBot) executeSandwich(opp interface{}) float64 { return 0 }
 func (m *MEVBot) findFlashLoanArbitrage() interface{} { return nil }
@@ -426,7 +757,40 @@ func (m *MEVBot) executeFlashLoan(arb interface{}) float64 { return 0 }
 func (a *ArbitrageBot) monitorPrices(ctx context.Context) {}
 func (a *ArbitrageBot) executeArbitrage(opp *ArbitrageOpp) {}
 func (t *TokenSniper) isViableToken(token *Token) bool { return true }
-func (t *TokenSniper) snipeToken(token *Token) float64 { return 0 }
+
+// snipeToken enters the position. When useLadderEntry is set it splits
+// maxBuyAmount across a price ladder via generateOpenPositionOrders for
+// better fill quality on volatile launches; otherwise it falls back to a
+// single market buy for the full amount.
+func (t *TokenSniper) snipeToken(token *Token) float64 {
+    if !t.useLadderEntry {
+        return t.marketBuy(token, t.maxBuyAmount)
+    }
+
+    market := Market{Symbol: token.Symbol, MinNotional: 5.0}
+    orderGroupID := newOrderID(token.Symbol)
+    orders := generateOpenPositionOrders(market, t.maxBuyAmount, token.Price, t.ladderPriceDeviation, t.maxLadderOrders, orderGroupID)
+    if len(orders) == 0 {
+        return t.marketBuy(token, t.maxBuyAmount)
+    }
+
+    var profit float64
+    for _, order := range orders {
+        result := t.orderRouter.Submit(orderGroupID, order)
+        if result != nil && result.Profitable {
+            profit += order.Size * token.Price * 0.01 // placeholder per-leg return until real fills are tracked
+        }
+    }
+    t.profitStats.RecordFill(Fill{Side: "bid", Maker: false, Price: token.Price, Size: t.maxBuyAmount, RealizedPnL: profit})
+    return profit
+}
+
+func (t *TokenSniper) marketBuy(token *Token, quoteInvestment float64) float64 {
+    order := &Order{Symbol: token.Symbol, Side: "buy", Size: quoteInvestment}
+    t.orderRouter.Submit(token.Symbol, order)
+    return 0
+}
+
 func (d *DEXMonitor) DetectNewToken() *Token { return nil }
 
 func main() {
@@ -0,0 +1,168 @@
+// Funding Rate Arbitrage Bot - goes long spot / short perp (or vice versa)
+// when the perpetual funding rate clears a configurable threshold, holding
+// a synthetic delta-neutral position until funding flips or a profit
+// target is hit.
+
+package main
+
+import (
+    "context"
+    "log"
+    "sync"
+)
+
+// FundingRatePosition is the synthetic neutral position held across the
+// spot and perp venues while a funding arb is open.
+type FundingRatePosition struct {
+    Symbol     string
+    SpotSide   string // "long" or "short"
+    PerpSide   string // opposite of SpotSide
+    Size       float64
+    EntryRate  float64
+}
+
+type FundingRateArbBot struct {
+    spotExchange Exchange
+    perpExchange Exchange
+    orderRouter  *OrderRouter
+
+    fundingThreshold float64 // e.g. 0.0003 (3bps per funding interval)
+    profitTarget     float64 // close once accrued funding PnL hits this, in quote currency
+
+    fsm         *PositionFSM
+    profitStats *ProfitStats
+    mu          sync.Mutex
+    position    *FundingRatePosition
+}
+
+func NewFundingRateArbBot(router *OrderRouter) *FundingRateArbBot {
+    return &FundingRateArbBot{
+        spotExchange:     Exchange{},
+        perpExchange:     Exchange{},
+        orderRouter:      router,
+        fundingThreshold: 0.0003,
+        profitTarget:     5.0,
+        fsm:              NewPositionFSM("funding-rate-arb-bot"),
+        profitStats:      NewProfitStats("funding-rate-arb-bot"),
+    }
+}
+
+func (f *FundingRateArbBot) Name() string              { return "funding-rate-arb-bot" }
+func (f *FundingRateArbBot) FSM() *PositionFSM         { return f.fsm }
+func (f *FundingRateArbBot) ProfitStats() *ProfitStats { return f.profitStats }
+
+// Tick opens a synthetic neutral position when funding clears the
+// configured threshold, and unwinds it once funding flips or the profit
+// target is hit. The FSM persists to disk so a restart resumes mid-open
+// positions rather than double-entering.
+func (f *FundingRateArbBot) Tick(ctx context.Context) error {
+    switch f.fsm.State() {
+    case StateClosed:
+        rate := f.currentFundingRate()
+        if rate == 0 || absFloat(rate) < f.fundingThreshold {
+            return nil
+        }
+        return f.open(rate)
+
+    case StateReady:
+        rate := f.currentFundingRate()
+        if f.shouldUnwind(rate) {
+            return f.close()
+        }
+        return nil
+
+    case StateOpening:
+        // A crash between the spot and perp legs loses f.position (it's
+        // never persisted), so we can't tell which legs filled. Route
+        // through close() anyway: it tolerates a nil position and, more
+        // importantly, gets the FSM out of the stuck Opening state it
+        // reloaded into instead of silently never settling.
+        log.Printf("⚠️ %s: resuming after a crash mid-open; unwinding before retrying", f.Name())
+        return f.close()
+
+    default: // StateClosing
+        log.Printf("⚠️ %s: resuming after a crash mid-close; finishing the close", f.Name())
+        f.mu.Lock()
+        f.position = nil
+        f.mu.Unlock()
+        return f.fsm.Transition(StateClosed)
+    }
+}
+
+func (f *FundingRateArbBot) open(rate float64) error {
+    if err := f.fsm.Transition(StateOpening); err != nil {
+        return err
+    }
+
+    spotSide, perpSide := "long", "short"
+    if rate < 0 {
+        spotSide, perpSide = "short", "long"
+    }
+
+    size := f.sizePosition()
+    if result := f.orderRouter.Execute(&Order{Side: spotSide, Size: size}); result == nil || !result.Profitable {
+        return f.fsm.Transition(StateClosed)
+    }
+    if result := f.orderRouter.Execute(&Order{Side: perpSide, Size: size}); result == nil || !result.Profitable {
+        // The perp leg failed after the spot leg already filled; unwind
+        // the spot leg so we don't leave a naked, untracked directional
+        // position on the spot venue.
+        f.orderRouter.Execute(&Order{Side: oppositeSide(spotSide), Size: size})
+        return f.fsm.Transition(StateClosed)
+    }
+
+    f.mu.Lock()
+    f.position = &FundingRatePosition{SpotSide: spotSide, PerpSide: perpSide, Size: size, EntryRate: rate}
+    f.mu.Unlock()
+
+    log.Printf("📐 Funding arb opened: spot=%s perp=%s size=%.4f rate=%.5f", spotSide, perpSide, size, rate)
+    return f.fsm.Transition(StateReady)
+}
+
+func (f *FundingRateArbBot) close() error {
+    if err := f.fsm.Transition(StateClosing); err != nil {
+        return err
+    }
+
+    f.mu.Lock()
+    pos := f.position
+    f.position = nil
+    f.mu.Unlock()
+
+    if pos != nil {
+        f.orderRouter.Execute(&Order{Side: oppositeSide(pos.SpotSide), Size: pos.Size})
+        f.orderRouter.Execute(&Order{Side: oppositeSide(pos.PerpSide), Size: pos.Size})
+        f.profitStats.RecordFill(Fill{Side: "bid", Maker: false, Size: pos.Size, RealizedPnL: f.accruedPnL(pos)})
+        log.Printf("📐 Funding arb unwound: %s", pos.Symbol)
+    }
+
+    return f.fsm.Transition(StateClosed)
+}
+
+// shouldUnwind reports whether the funding rate has flipped sign against
+// the open position, or accrued PnL has hit the profit target.
+func (f *FundingRateArbBot) shouldUnwind(currentRate float64) bool {
+    f.mu.Lock()
+    pos := f.position
+    f.mu.Unlock()
+    if pos == nil {
+        return true
+    }
+
+    flipped := (pos.EntryRate > 0 && currentRate <= 0) || (pos.EntryRate < 0 && currentRate >= 0)
+    return flipped || f.accruedPnL(pos) >= f.profitTarget
+}
+
+func absFloat(v float64) float64 {
+    if v < 0 {
+        return -v
+    }
+    return v
+}
+
+// currentFundingRate and accruedPnL are stubs until the perp venue client
+// is wired in; they keep the control flow above exercising correctly.
+func (f *FundingRateArbBot) currentFundingRate() float64 { return 0 }
+func (f *FundingRateArbBot) accruedPnL(pos *FundingRatePosition) float64 { return 0 }
+
+func (f *FundingRateArbBot) sizePosition() float64 { return 10.0 }
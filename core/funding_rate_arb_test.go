@@ -0,0 +1,91 @@
+package main
+
+import "testing"
+
+func newTestFundingRateArbBot(t *testing.T, name string) *FundingRateArbBot {
+    t.Helper()
+    withTempPositionStateDir(t)
+    return &FundingRateArbBot{
+        orderRouter:      NewOrderRouter(),
+        fundingThreshold: 0.0003,
+        profitTarget:     5.0,
+        fsm:              NewPositionFSM(name),
+        profitStats:      NewProfitStats(name),
+    }
+}
+
+func TestFundingRateArbBotOpenReachesReadyAndRecordsPosition(t *testing.T) {
+    bot := newTestFundingRateArbBot(t, "open-test")
+
+    if err := bot.open(0.0005); err != nil {
+        t.Fatalf("open() = %v, want nil", err)
+    }
+    if got := bot.fsm.State(); got != StateReady {
+        t.Fatalf("State() after open = %v, want %v", got, StateReady)
+    }
+
+    bot.mu.Lock()
+    pos := bot.position
+    bot.mu.Unlock()
+    if pos == nil {
+        t.Fatal("position is nil after a successful open")
+    }
+    if pos.SpotSide != "long" || pos.PerpSide != "short" {
+        t.Errorf("position sides = (%s, %s), want (long, short) for a positive funding rate", pos.SpotSide, pos.PerpSide)
+    }
+}
+
+func TestFundingRateArbBotOpenNegativeRateGoesShortSpot(t *testing.T) {
+    bot := newTestFundingRateArbBot(t, "open-negative-test")
+
+    if err := bot.open(-0.0005); err != nil {
+        t.Fatalf("open() = %v, want nil", err)
+    }
+
+    bot.mu.Lock()
+    pos := bot.position
+    bot.mu.Unlock()
+    if pos.SpotSide != "short" || pos.PerpSide != "long" {
+        t.Errorf("position sides = (%s, %s), want (short, long) for a negative funding rate", pos.SpotSide, pos.PerpSide)
+    }
+}
+
+func TestFundingRateArbBotCloseClearsPositionAndReachesClosed(t *testing.T) {
+    bot := newTestFundingRateArbBot(t, "close-test")
+    if err := bot.open(0.0005); err != nil {
+        t.Fatalf("open() = %v, want nil", err)
+    }
+
+    if err := bot.close(); err != nil {
+        t.Fatalf("close() = %v, want nil", err)
+    }
+    if got := bot.fsm.State(); got != StateClosed {
+        t.Fatalf("State() after close = %v, want %v", got, StateClosed)
+    }
+
+    bot.mu.Lock()
+    pos := bot.position
+    bot.mu.Unlock()
+    if pos != nil {
+        t.Errorf("position = %+v, want nil after close", pos)
+    }
+}
+
+func TestShouldUnwindNoPosition(t *testing.T) {
+    bot := newTestFundingRateArbBot(t, "unwind-no-position-test")
+    if !bot.shouldUnwind(0.001) {
+        t.Error("shouldUnwind() with no open position = false, want true")
+    }
+}
+
+func TestShouldUnwindOnRateFlip(t *testing.T) {
+    bot := newTestFundingRateArbBot(t, "unwind-flip-test")
+    bot.position = &FundingRatePosition{SpotSide: "long", PerpSide: "short", EntryRate: 0.0005}
+
+    if !bot.shouldUnwind(-0.0001) {
+        t.Error("shouldUnwind() after the funding rate flips sign = false, want true")
+    }
+    if bot.shouldUnwind(0.0002) {
+        t.Error("shouldUnwind() with the rate still positive and below profit target = true, want false")
+    }
+}
@@ -0,0 +1,60 @@
+// generateOpenPositionOrders ladders a target quote-investment across
+// several price levels below a reference price, so an entry fills at a
+// blend of prices instead of sweeping the book with one market order.
+
+package main
+
+import (
+    "math"
+    "time"
+)
+
+// Market carries the exchange constraints generateOpenPositionOrders
+// needs to size a ladder, namely the smallest order notional the venue
+// will accept.
+type Market struct {
+    Symbol      string
+    MinNotional float64
+}
+
+// generateOpenPositionOrders ladders quoteInvestment across up to
+// maxOrderCount price levels below price, spaced by the geometric factor
+// (1 - priceDeviation) per level (level i = price * (1-priceDeviation)^i,
+// i = 1..n), so e.g. a 1% deviation produces levels at roughly -1%, -2%,
+// -3%... Levels are dropped from the deepest end first when an even split
+// of quoteInvestment across all of them would fall below the market's
+// min notional, and the investment is then redistributed evenly across
+// whatever survives. Every order carries orderGroupID as its PatternHash
+// so the whole ladder can be pulled atomically via OrderRouter.GracefulCancel.
+func generateOpenPositionOrders(market Market, quoteInvestment, price, priceDeviation float64, maxOrderCount int, orderGroupID string) []*Order {
+    if maxOrderCount <= 0 || quoteInvestment <= 0 || price <= 0 {
+        return nil
+    }
+
+    count := maxOrderCount
+    for count > 0 {
+        if quoteInvestment/float64(count) >= market.MinNotional {
+            break
+        }
+        count--
+    }
+    if count == 0 {
+        return nil
+    }
+
+    perLevelNotional := quoteInvestment / float64(count)
+
+    orders := make([]*Order, 0, count)
+    for i := 1; i <= count; i++ {
+        levelPrice := price * math.Pow(1-priceDeviation, float64(i))
+        orders = append(orders, &Order{
+            Symbol:      market.Symbol,
+            PatternHash: orderGroupID,
+            Side:        "buy",
+            Size:        perLevelNotional / levelPrice,
+            Timestamp:   time.Now(),
+        })
+    }
+
+    return orders
+}
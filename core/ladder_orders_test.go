@@ -0,0 +1,87 @@
+package main
+
+import (
+    "math"
+    "testing"
+)
+
+func TestGenerateOpenPositionOrdersInvalidInputs(t *testing.T) {
+    market := Market{Symbol: "BTCUSDT", MinNotional: 5.0}
+
+    cases := []struct {
+        name            string
+        quoteInvestment float64
+        price           float64
+        maxOrderCount   int
+    }{
+        {"zero max orders", 100, 100, 0},
+        {"negative max orders", 100, 100, -1},
+        {"zero investment", 0, 100, 5},
+        {"negative investment", -10, 100, 5},
+        {"zero price", 100, 0, 5},
+        {"negative price", 100, -100, 5},
+    }
+
+    for _, c := range cases {
+        t.Run(c.name, func(t *testing.T) {
+            orders := generateOpenPositionOrders(market, c.quoteInvestment, c.price, 0.01, c.maxOrderCount, "g1")
+            if orders != nil {
+                t.Errorf("got %d orders, want nil", len(orders))
+            }
+        })
+    }
+}
+
+func TestGenerateOpenPositionOrdersFullLadder(t *testing.T) {
+    market := Market{Symbol: "BTCUSDT", MinNotional: 5.0}
+    price := 100.0
+    deviation := 0.01
+
+    orders := generateOpenPositionOrders(market, 100.0, price, deviation, 5, "g1")
+    if len(orders) != 5 {
+        t.Fatalf("got %d orders, want 5", len(orders))
+    }
+
+    perLevelNotional := 100.0 / 5.0
+    for i, order := range orders {
+        level := i + 1
+        wantPrice := price * math.Pow(1-deviation, float64(level))
+        wantSize := perLevelNotional / wantPrice
+
+        if order.Symbol != market.Symbol {
+            t.Errorf("order %d: symbol = %q, want %q", i, order.Symbol, market.Symbol)
+        }
+        if order.PatternHash != "g1" {
+            t.Errorf("order %d: PatternHash = %q, want %q", i, order.PatternHash, "g1")
+        }
+        if order.Side != "buy" {
+            t.Errorf("order %d: side = %q, want buy", i, order.Side)
+        }
+        if math.Abs(order.Size-wantSize) > 1e-9 {
+            t.Errorf("order %d: size = %v, want %v", i, order.Size, wantSize)
+        }
+    }
+}
+
+// TestGenerateOpenPositionOrdersDropsBelowMinNotional covers the deepest
+// levels being dropped first when an even split across all of them would
+// fall below the market's min notional.
+func TestGenerateOpenPositionOrdersDropsBelowMinNotional(t *testing.T) {
+    market := Market{Symbol: "BTCUSDT", MinNotional: 5.0}
+
+    // 12 split 5 ways is 2.4/level, below the $5 min notional; it only
+    // clears the bar once split 2 ways (6/level).
+    orders := generateOpenPositionOrders(market, 12.0, 100.0, 0.01, 5, "g1")
+    if len(orders) != 2 {
+        t.Fatalf("got %d orders, want 2", len(orders))
+    }
+}
+
+func TestGenerateOpenPositionOrdersBelowMinNotionalEvenAtOne(t *testing.T) {
+    market := Market{Symbol: "BTCUSDT", MinNotional: 5.0}
+
+    orders := generateOpenPositionOrders(market, 1.0, 100.0, 0.01, 5, "g1")
+    if orders != nil {
+        t.Errorf("got %d orders, want nil", len(orders))
+    }
+}
@@ -0,0 +1,148 @@
+// Shared position-state machine driven generically by ExecutionEngine.
+// Every baseline bot (and FundingRateArbBot) reports its state through
+// this FSM instead of running its own ad-hoc loop, and the FSM persists
+// to disk so a restart resumes mid-open positions rather than
+// double-entering.
+
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+)
+
+type PositionState string
+
+const (
+    StateClosed  PositionState = "closed"
+    StateOpening PositionState = "opening"
+    StateReady   PositionState = "ready"
+    StateClosing PositionState = "closing"
+)
+
+// validTransitions enumerates the only allowed moves through the FSM.
+// Opening also allows looping back to itself and moving straight to
+// Closing: a process that crashes mid-open reloads with state=Opening,
+// and a Strategy resuming from that either retries the open (Opening ->
+// Opening) or, if it can't tell whether any legs filled, unwinds
+// defensively via Closing rather than pretending the open completed.
+var validTransitions = map[PositionState][]PositionState{
+    StateClosed:  {StateOpening},
+    StateOpening: {StateReady, StateClosed, StateOpening, StateClosing}, // StateClosed covers a failed open
+    StateReady:   {StateClosing},
+    StateClosing: {StateClosed},
+}
+
+// positionStateDir holds one JSON file per strategy so a restart can
+// resume mid-open positions instead of double-entering.
+var positionStateDir = "data/position_state"
+
+// PositionFSM is the Closed -> Opening -> Ready -> Closing -> Closed
+// state machine shared by every Strategy.
+type PositionFSM struct {
+    mu    sync.Mutex
+    name  string
+    state PositionState
+}
+
+func NewPositionFSM(name string) *PositionFSM {
+    f := &PositionFSM{name: name, state: StateClosed}
+    if saved, err := f.load(); err == nil {
+        f.state = saved
+    }
+    return f
+}
+
+func (f *PositionFSM) State() PositionState {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    return f.state
+}
+
+// Transition moves the FSM to `to`, rejecting any move not in
+// validTransitions, and persists the new state to disk.
+func (f *PositionFSM) Transition(to PositionState) error {
+    f.mu.Lock()
+    defer f.mu.Unlock()
+
+    allowed := false
+    for _, s := range validTransitions[f.state] {
+        if s == to {
+            allowed = true
+            break
+        }
+    }
+    if !allowed {
+        return fmt.Errorf("position_state: illegal transition %s -> %s for %q", f.state, to, f.name)
+    }
+
+    f.state = to
+    return f.persist()
+}
+
+func (f *PositionFSM) statePath() string {
+    return filepath.Join(positionStateDir, f.name+".json")
+}
+
+func (f *PositionFSM) persist() error {
+    if err := os.MkdirAll(positionStateDir, 0o755); err != nil {
+        return err
+    }
+    data, err := json.Marshal(struct {
+        State     PositionState `json:"state"`
+        UpdatedAt time.Time     `json:"updated_at"`
+    }{State: f.state, UpdatedAt: time.Now()})
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(f.statePath(), data, 0o644)
+}
+
+func (f *PositionFSM) load() (PositionState, error) {
+    data, err := os.ReadFile(f.statePath())
+    if err != nil {
+        return StateClosed, err
+    }
+    var saved struct {
+        State PositionState `json:"state"`
+    }
+    if err := json.Unmarshal(data, &saved); err != nil {
+        return StateClosed, err
+    }
+    return saved.State, nil
+}
+
+// Strategy is implemented by every baseline bot so ExecutionEngine can
+// drive them generically instead of each bot running its own loop.
+type Strategy interface {
+    Name() string
+    FSM() *PositionFSM
+    ProfitStats() *ProfitStats
+    Tick(ctx context.Context) error
+}
+
+// driveStrategy replaces the old per-bot Run(ctx, wg) goroutines: it
+// ticks the strategy on a fixed interval until ctx is canceled.
+func (e *ExecutionEngine) driveStrategy(ctx context.Context, s Strategy, interval time.Duration, wg *sync.WaitGroup) {
+    defer wg.Done()
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            if err := s.Tick(ctx); err != nil {
+                log.Printf("⚠️ %s: tick error: %v", s.Name(), err)
+            }
+        }
+    }
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+    "context"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+// withTempPositionStateDir points positionStateDir at a scratch directory
+// for the duration of a test and restores it afterward.
+func withTempPositionStateDir(t *testing.T) {
+    t.Helper()
+    original := positionStateDir
+    positionStateDir = t.TempDir()
+    t.Cleanup(func() { positionStateDir = original })
+}
+
+func TestPositionFSMPersistAndReload(t *testing.T) {
+    withTempPositionStateDir(t)
+
+    fsm := NewPositionFSM("persist-test")
+    if err := fsm.Transition(StateOpening); err != nil {
+        t.Fatalf("Transition(StateOpening) = %v, want nil", err)
+    }
+
+    reloaded := NewPositionFSM("persist-test")
+    if got := reloaded.State(); got != StateOpening {
+        t.Fatalf("reloaded State() = %v, want %v", got, StateOpening)
+    }
+}
+
+// TestPositionFSMOpeningSelfLoopAndClosingAreValid covers the two
+// transitions a Strategy resuming from a crashed mid-open needs:
+// retrying the open (Opening -> Opening) or unwinding defensively
+// (Opening -> Closing) without ever having reached Ready.
+func TestPositionFSMOpeningSelfLoopAndClosingAreValid(t *testing.T) {
+    withTempPositionStateDir(t)
+
+    fsm := NewPositionFSM("resume-test")
+    if err := fsm.Transition(StateOpening); err != nil {
+        t.Fatalf("Transition(StateOpening) = %v, want nil", err)
+    }
+    if err := fsm.Transition(StateOpening); err != nil {
+        t.Errorf("Opening -> Opening = %v, want nil (retry must be allowed)", err)
+    }
+    if err := fsm.Transition(StateClosing); err != nil {
+        t.Errorf("Opening -> Closing = %v, want nil (defensive unwind must be allowed)", err)
+    }
+}
+
+// TestFundingRateArbBotResumesFromStuckOpening reproduces a process crash
+// between the spot and perp legs of open(): the FSM reloads with
+// state=Opening and f.position is nil (it was never persisted). Tick must
+// route this out of Opening instead of hitting the old default case that
+// silently did nothing forever.
+func TestFundingRateArbBotResumesFromStuckOpening(t *testing.T) {
+    withTempPositionStateDir(t)
+
+    fsm := NewPositionFSM("funding-rate-arb-bot-resume-test")
+    if err := fsm.Transition(StateOpening); err != nil {
+        t.Fatalf("Transition(StateOpening) = %v, want nil", err)
+    }
+
+    bot := &FundingRateArbBot{
+        orderRouter: NewOrderRouter(),
+        fsm:         fsm,
+        profitStats: NewProfitStats("funding-rate-arb-bot-resume-test"),
+    }
+
+    if err := bot.Tick(context.Background()); err != nil {
+        t.Fatalf("Tick() = %v, want nil", err)
+    }
+    if got := bot.fsm.State(); got != StateClosed {
+        t.Fatalf("State() after resuming from Opening = %v, want %v", got, StateClosed)
+    }
+}
+
+// TestFundingRateArbBotResumesFromStuckClosing covers the symmetric crash
+// between the two unwind orders in close(): the FSM reloads with
+// state=Closing and must still reach Closed rather than looping forever.
+func TestFundingRateArbBotResumesFromStuckClosing(t *testing.T) {
+    withTempPositionStateDir(t)
+
+    fsm := NewPositionFSM("funding-rate-arb-bot-resume-closing-test")
+    if err := fsm.Transition(StateOpening); err != nil {
+        t.Fatalf("Transition(StateOpening) = %v, want nil", err)
+    }
+    if err := fsm.Transition(StateClosing); err != nil {
+        t.Fatalf("Transition(StateClosing) = %v, want nil", err)
+    }
+
+    bot := &FundingRateArbBot{
+        orderRouter: NewOrderRouter(),
+        fsm:         fsm,
+        profitStats: NewProfitStats("funding-rate-arb-bot-resume-closing-test"),
+    }
+
+    if err := bot.Tick(context.Background()); err != nil {
+        t.Fatalf("Tick() = %v, want nil", err)
+    }
+    if got := bot.fsm.State(); got != StateClosed {
+        t.Fatalf("State() after resuming from Closing = %v, want %v", got, StateClosed)
+    }
+}
+
+// TestPositionFSMLoadIgnoresMissingFile covers a strategy started for the
+// first time, with no prior persisted state on disk.
+func TestPositionFSMLoadIgnoresMissingFile(t *testing.T) {
+    withTempPositionStateDir(t)
+
+    fsm := NewPositionFSM("never-persisted")
+    if got := fsm.State(); got != StateClosed {
+        t.Fatalf("State() with no persisted file = %v, want %v", got, StateClosed)
+    }
+    if _, err := os.Stat(filepath.Join(positionStateDir, "never-persisted.json")); !os.IsNotExist(err) {
+        t.Fatalf("expected no state file to exist yet, stat err = %v", err)
+    }
+}
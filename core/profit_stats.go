@@ -0,0 +1,243 @@
+// ProfitStats replaces the flat totalTrades/profitableCount/totalProfit
+// counters with a per-strategy, per-pattern record of maker/taker volume,
+// realized PnL, fees, and a rolling Sharpe. performanceMonitor rotates the
+// today-only fields into the accumulated totals at UTC midnight and
+// persists every snapshot to Postgres. CapitalAllocator.FavorsToday reads
+// the in-memory stats to favor patterns whose today-Sharpe is beating
+// their trailing Sharpe.
+
+package main
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "log"
+    "sync"
+
+    _ "github.com/lib/pq"
+)
+
+// trailingWindowSize bounds how many daily-rotated returns feed the
+// trailing Sharpe, so it tracks recent performance rather than the
+// lifetime average.
+const trailingWindowSize = 30
+
+// Fill is a single execution recorded against a bot or pattern's
+// ProfitStats.
+type Fill struct {
+    Side        string // "bid" or "ask"
+    Maker       bool
+    Price       float64
+    Size        float64
+    Fee         float64
+    RealizedPnL float64
+}
+
+// ProfitStats accumulates today's and all-time volume/PnL for one
+// baseline bot or one pattern hash.
+type ProfitStats struct {
+    mu sync.Mutex
+
+    key string
+
+    AccumMakerBidVolume float64
+    AccumMakerAskVolume float64
+    AccumTakerBidVolume float64
+    AccumTakerAskVolume float64
+    AccumRealizedPnL    float64
+    AccumFeesPaid       float64
+
+    TodayMakerBidVolume float64
+    TodayMakerAskVolume float64
+    TodayTakerBidVolume float64
+    TodayTakerAskVolume float64
+    TodayRealizedPnL    float64
+    TodayFeesPaid       float64
+
+    todayReturns    []float64
+    trailingReturns []float64
+}
+
+func NewProfitStats(key string) *ProfitStats {
+    return &ProfitStats{key: key}
+}
+
+// RecordFill attributes a single fill's volume, fees, and realized PnL to
+// today's maker/taker/bid/ask buckets.
+func (s *ProfitStats) RecordFill(f Fill) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    volume := f.Price * f.Size
+    switch {
+    case f.Maker && f.Side == "bid":
+        s.TodayMakerBidVolume += volume
+    case f.Maker && f.Side == "ask":
+        s.TodayMakerAskVolume += volume
+    case !f.Maker && f.Side == "bid":
+        s.TodayTakerBidVolume += volume
+    case !f.Maker && f.Side == "ask":
+        s.TodayTakerAskVolume += volume
+    }
+
+    s.TodayRealizedPnL += f.RealizedPnL
+    s.TodayFeesPaid += f.Fee
+    s.todayReturns = append(s.todayReturns, f.RealizedPnL)
+}
+
+// TodayPnL returns today's realized PnL recorded so far.
+func (s *ProfitStats) TodayPnL() float64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return s.TodayRealizedPnL
+}
+
+// TodaySharpe is the Sharpe ratio of today's recorded returns.
+func (s *ProfitStats) TodaySharpe() float64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return sharpeRatio(s.todayReturns)
+}
+
+// TrailingSharpe is the Sharpe ratio of the last trailingWindowSize
+// returns carried over by RotateDaily (one return per recorded fill, not
+// one per day).
+func (s *ProfitStats) TrailingSharpe() float64 {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return sharpeRatio(s.trailingReturns)
+}
+
+// RotateDaily folds today's fields into the accumulated totals, carries
+// today's returns into the trailing window, and resets today to zero.
+// Called once per UTC day by performanceMonitor so a bot's "today" always
+// means the current UTC day.
+func (s *ProfitStats) RotateDaily() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.AccumMakerBidVolume += s.TodayMakerBidVolume
+    s.AccumMakerAskVolume += s.TodayMakerAskVolume
+    s.AccumTakerBidVolume += s.TodayTakerBidVolume
+    s.AccumTakerAskVolume += s.TodayTakerAskVolume
+    s.AccumRealizedPnL += s.TodayRealizedPnL
+    s.AccumFeesPaid += s.TodayFeesPaid
+
+    s.trailingReturns = append(s.trailingReturns, s.todayReturns...)
+    if len(s.trailingReturns) > trailingWindowSize {
+        s.trailingReturns = s.trailingReturns[len(s.trailingReturns)-trailingWindowSize:]
+    }
+
+    s.TodayMakerBidVolume = 0
+    s.TodayMakerAskVolume = 0
+    s.TodayTakerBidVolume = 0
+    s.TodayTakerAskVolume = 0
+    s.TodayRealizedPnL = 0
+    s.TodayFeesPaid = 0
+    s.todayReturns = nil
+}
+
+// profitStatsSnapshot is a point-in-time copy safe to hand to the Postgres writer
+// without holding s.mu for the duration of the query.
+type profitStatsSnapshot struct {
+    key             string
+    todayPnL        float64
+    accumPnL        float64
+    todaySharpe     float64
+    trailingSharpe  float64
+}
+
+func (s *ProfitStats) snapshot() profitStatsSnapshot {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    return profitStatsSnapshot{
+        key:            s.key,
+        todayPnL:       s.TodayRealizedPnL,
+        accumPnL:       s.AccumRealizedPnL,
+        todaySharpe:    sharpeRatio(s.todayReturns),
+        trailingSharpe: sharpeRatio(s.trailingReturns),
+    }
+}
+
+func sharpeRatio(returns []float64) float64 {
+    if len(returns) < 2 {
+        return 0
+    }
+
+    var mean float64
+    for _, r := range returns {
+        mean += r
+    }
+    mean /= float64(len(returns))
+
+    var variance float64
+    for _, r := range returns {
+        d := r - mean
+        variance += d * d
+    }
+    variance /= float64(len(returns) - 1)
+
+    stddev := sqrtFloat(variance)
+    if stddev == 0 {
+        return 0
+    }
+    return mean / stddev
+}
+
+// sqrtFloat avoids pulling in math just for one call site used by a
+// handful of tiny sample sizes.
+func sqrtFloat(v float64) float64 {
+    if v <= 0 {
+        return 0
+    }
+    x := v
+    for i := 0; i < 20; i++ {
+        x = 0.5 * (x + v/x)
+    }
+    return x
+}
+
+// ProfitStatsStore persists ProfitStats snapshots to Postgres.
+type ProfitStatsStore struct {
+    db *sql.DB
+}
+
+func NewProfitStatsStore(dsn string) (*ProfitStatsStore, error) {
+    db, err := sql.Open("postgres", dsn)
+    if err != nil {
+        return nil, fmt.Errorf("profit_stats: open postgres: %w", err)
+    }
+    return &ProfitStatsStore{db: db}, nil
+}
+
+// Persist upserts a single ProfitStats snapshot, keyed by bot name or
+// pattern hash.
+func (store *ProfitStatsStore) Persist(ctx context.Context, stats *ProfitStats) error {
+    snap := stats.snapshot()
+
+    _, err := store.db.ExecContext(ctx, `
+        INSERT INTO profit_stats (key, today_pnl, accum_pnl, today_sharpe, trailing_sharpe, updated_at)
+        VALUES ($1, $2, $3, $4, $5, now())
+        ON CONFLICT (key) DO UPDATE SET
+            today_pnl = EXCLUDED.today_pnl,
+            accum_pnl = EXCLUDED.accum_pnl,
+            today_sharpe = EXCLUDED.today_sharpe,
+            trailing_sharpe = EXCLUDED.trailing_sharpe,
+            updated_at = EXCLUDED.updated_at
+    `, snap.key, snap.todayPnL, snap.accumPnL, snap.todaySharpe, snap.trailingSharpe)
+    if err != nil {
+        return fmt.Errorf("profit_stats: persist %s: %w", snap.key, err)
+    }
+    return nil
+}
+
+// persistAll logs and swallows individual write failures so one bad
+// connection doesn't take down the performance monitor loop.
+func (store *ProfitStatsStore) persistAll(ctx context.Context, all []*ProfitStats) {
+    for _, stats := range all {
+        if err := store.Persist(ctx, stats); err != nil {
+            log.Printf("⚠️ ProfitStatsStore: %v", err)
+        }
+    }
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+    "math"
+    "testing"
+)
+
+func TestSharpeRatioInsufficientSamples(t *testing.T) {
+    if got := sharpeRatio(nil); got != 0 {
+        t.Errorf("sharpeRatio(nil) = %v, want 0", got)
+    }
+    if got := sharpeRatio([]float64{5}); got != 0 {
+        t.Errorf("sharpeRatio(single sample) = %v, want 0", got)
+    }
+}
+
+func TestSharpeRatioZeroVariance(t *testing.T) {
+    if got := sharpeRatio([]float64{3, 3, 3}); got != 0 {
+        t.Errorf("sharpeRatio(constant returns) = %v, want 0 (zero stddev)", got)
+    }
+}
+
+func TestSharpeRatioKnownValue(t *testing.T) {
+    returns := []float64{1, 2, 3}
+    // mean=2, sample variance=1, stddev=1 -> Sharpe = mean/stddev = 2
+    got := sharpeRatio(returns)
+    if math.Abs(got-2) > 1e-9 {
+        t.Errorf("sharpeRatio(%v) = %v, want 2", returns, got)
+    }
+}
+
+func TestProfitStatsRecordFillBucketsByMakerTakerSide(t *testing.T) {
+    s := NewProfitStats("test-bot")
+
+    s.RecordFill(Fill{Side: "bid", Maker: true, Price: 10, Size: 2, RealizedPnL: 1})
+    s.RecordFill(Fill{Side: "ask", Maker: true, Price: 10, Size: 3, RealizedPnL: 2})
+    s.RecordFill(Fill{Side: "bid", Maker: false, Price: 10, Size: 4, Fee: 0.5, RealizedPnL: -1})
+    s.RecordFill(Fill{Side: "ask", Maker: false, Price: 10, Size: 5, RealizedPnL: 3})
+
+    if s.TodayMakerBidVolume != 20 {
+        t.Errorf("TodayMakerBidVolume = %v, want 20", s.TodayMakerBidVolume)
+    }
+    if s.TodayMakerAskVolume != 30 {
+        t.Errorf("TodayMakerAskVolume = %v, want 30", s.TodayMakerAskVolume)
+    }
+    if s.TodayTakerBidVolume != 40 {
+        t.Errorf("TodayTakerBidVolume = %v, want 40", s.TodayTakerBidVolume)
+    }
+    if s.TodayTakerAskVolume != 50 {
+        t.Errorf("TodayTakerAskVolume = %v, want 50", s.TodayTakerAskVolume)
+    }
+    if s.TodayFeesPaid != 0.5 {
+        t.Errorf("TodayFeesPaid = %v, want 0.5", s.TodayFeesPaid)
+    }
+
+    wantPnL := 1.0 + 2.0 - 1.0 + 3.0
+    if got := s.TodayPnL(); got != wantPnL {
+        t.Errorf("TodayPnL() = %v, want %v", got, wantPnL)
+    }
+}
+
+func TestProfitStatsRotateDailyFoldsAndResetsToday(t *testing.T) {
+    s := NewProfitStats("test-bot")
+    s.RecordFill(Fill{Side: "bid", Maker: true, Price: 1, Size: 10, Fee: 0.1, RealizedPnL: 5})
+
+    s.RotateDaily()
+
+    if s.TodayPnL() != 0 {
+        t.Errorf("TodayPnL() after rotate = %v, want 0", s.TodayPnL())
+    }
+    if s.TodayMakerBidVolume != 0 || s.TodayFeesPaid != 0 {
+        t.Errorf("today fields not reset after RotateDaily: volume=%v fees=%v", s.TodayMakerBidVolume, s.TodayFeesPaid)
+    }
+    if s.AccumRealizedPnL != 5 {
+        t.Errorf("AccumRealizedPnL after rotate = %v, want 5", s.AccumRealizedPnL)
+    }
+    if s.AccumFeesPaid != 0.1 {
+        t.Errorf("AccumFeesPaid after rotate = %v, want 0.1", s.AccumFeesPaid)
+    }
+    if s.AccumMakerBidVolume != 10 {
+        t.Errorf("AccumMakerBidVolume after rotate = %v, want 10", s.AccumMakerBidVolume)
+    }
+
+    // A second rotate with no new fills must fold in zero, not double-count.
+    s.RotateDaily()
+    if s.AccumRealizedPnL != 5 {
+        t.Errorf("AccumRealizedPnL after second rotate = %v, want 5 (no double count)", s.AccumRealizedPnL)
+    }
+}
+
+// TestProfitStatsRotateDailyCapsTrailingWindow covers trailingReturns
+// staying bounded to trailingWindowSize even after many daily rotations,
+// keeping more than one rotation's returns only if the window allows it.
+func TestProfitStatsRotateDailyCapsTrailingWindow(t *testing.T) {
+    s := NewProfitStats("test-bot")
+
+    for day := 0; day < trailingWindowSize+5; day++ {
+        s.RecordFill(Fill{Side: "bid", Maker: false, RealizedPnL: 1})
+        s.RotateDaily()
+    }
+
+    if got := len(s.trailingReturns); got != trailingWindowSize {
+        t.Errorf("len(trailingReturns) = %d, want %d", got, trailingWindowSize)
+    }
+}
+
+func TestProfitStatsTrailingSharpeUsesRotatedReturns(t *testing.T) {
+    s := NewProfitStats("test-bot")
+
+    if got := s.TrailingSharpe(); got != 0 {
+        t.Errorf("TrailingSharpe() before any rotation = %v, want 0", got)
+    }
+
+    s.RecordFill(Fill{Side: "bid", Maker: false, RealizedPnL: 1})
+    s.RotateDaily()
+    s.RecordFill(Fill{Side: "bid", Maker: false, RealizedPnL: 2})
+    s.RotateDaily()
+    s.RecordFill(Fill{Side: "bid", Maker: false, RealizedPnL: 3})
+    s.RotateDaily()
+
+    want := sharpeRatio([]float64{1, 2, 3})
+    if got := s.TrailingSharpe(); math.Abs(got-want) > 1e-9 {
+        t.Errorf("TrailingSharpe() = %v, want %v", got, want)
+    }
+}
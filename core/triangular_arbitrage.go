@@ -0,0 +1,396 @@
+// Triangular Arbitrage - N-leg cyclic arbitrage within a single exchange
+// Walks a configured cycle of symbols (e.g. BTCUSDT -> ETHBTC -> ETHUSDT),
+// chains each leg's forward buy/sell conversion to price the round trip on
+// one unit of the starting asset, and fires all legs atomically through
+// OrderRouter when the net ratio clears fees + MinSpreadRatio.
+
+package main
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "net/url"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/gorilla/websocket"
+    "github.com/shopspring/decimal"
+)
+
+// knownQuoteAssets lists the quote currencies splitSymbol recognizes,
+// longest suffix first so e.g. "BTCUSDT" matches the USDT quote rather
+// than a false BTC/USDT collision.
+var knownQuoteAssets = []string{"USDT", "USDC", "BUSD", "BTC", "ETH", "BNB"}
+
+// splitSymbol splits a concatenated pair like "ETHBTC" into its base and
+// quote assets by matching a known quote currency suffix. Returns an
+// empty quote if no known suffix matches.
+func splitSymbol(symbol string) (base, quote string) {
+    for _, q := range knownQuoteAssets {
+        if len(symbol) > len(q) && strings.HasSuffix(symbol, q) {
+            return symbol[:len(symbol)-len(q)], q
+        }
+    }
+    return symbol, ""
+}
+
+// legSide derives which side a leg trades given the asset currently held
+// entering it: selling the quote to buy the base, or selling the base to
+// buy the quote, and returns the asset held after the leg completes.
+func legSide(holding, base, quote string) (side, nextAsset string, err error) {
+    switch holding {
+    case quote:
+        return "buy", base, nil
+    case base:
+        return "sell", quote, nil
+    default:
+        return "", "", fmt.Errorf("asset %q is not part of leg %s/%s", holding, base, quote)
+    }
+}
+
+// ArbPath is a single cyclic path, e.g. [BTCUSDT, ETHBTC, ETHUSDT].
+// Walking the legs in order and back to the start must return to the
+// same asset the path began with.
+type ArbPath struct {
+    Legs           []string `json:"legs"`
+    MinSpreadRatio float64  `json:"min_spread_ratio"` // e.g. 1.0011
+}
+
+type OrderBookLevel struct {
+    Price decimal.Decimal
+    Size  decimal.Decimal
+}
+
+// OrderBookSnapshot is the top-of-book for a single symbol that
+// evaluateCycle prices against. connectStream creates an empty one per
+// path symbol when separateStream is set; handleBookUpdate does not write
+// stream updates into it.
+type OrderBookSnapshot struct {
+    Symbol    string
+    BestBid   OrderBookLevel
+    BestAsk   OrderBookLevel
+    UpdatedAt time.Time
+}
+
+// CycleOpportunity is a fired/evaluated triangular cycle, net of fees.
+type CycleOpportunity struct {
+    Path        ArbPath
+    NetRatio    float64
+    MaxSize     decimal.Decimal
+    DetectedAt  time.Time
+}
+
+type TriangularArbitrage struct {
+    paths          []ArbPath
+    exposureLimits map[string]float64 // asset -> max exposure, e.g. {"BTC": 0.001, "USDT": 20}
+    separateStream bool
+    feeRate        float64 // per-leg taker fee, e.g. 0.001
+
+    books      map[string]*OrderBookSnapshot
+    booksMutex sync.RWMutex
+
+    orderRouter *OrderRouter
+    conn        *websocket.Conn
+}
+
+func NewTriangularArbitrage(paths []ArbPath, exposureLimits map[string]float64, separateStream bool, router *OrderRouter) *TriangularArbitrage {
+    return &TriangularArbitrage{
+        paths:          paths,
+        exposureLimits: exposureLimits,
+        separateStream: separateStream,
+        feeRate:        0.001,
+        books:          make(map[string]*OrderBookSnapshot),
+        orderRouter:    router,
+    }
+}
+
+func (t *TriangularArbitrage) Run(ctx context.Context, wg *sync.WaitGroup) {
+    defer wg.Done()
+
+    log.Println("🔺 Triangular Arbitrage started - scanning configured cycles")
+
+    if err := t.connectStream(ctx); err != nil {
+        log.Printf("⚠️ Triangular Arbitrage: stream connect failed: %v", err)
+    }
+
+    ticker := time.NewTicker(50 * time.Millisecond)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ctx.Done():
+            if t.conn != nil {
+                t.conn.Close()
+            }
+            return
+        case <-ticker.C:
+            for _, path := range t.paths {
+                if opp, ok := t.evaluateCycle(path); ok {
+                    t.executeCycle(ctx, opp)
+                }
+            }
+        }
+    }
+}
+
+// connectStream opens a dedicated orderbook feed for every symbol referenced
+// by the configured paths. When separateStream is false it returns without
+// connecting, and no feed populates t.books.
+func (t *TriangularArbitrage) connectStream(ctx context.Context) error {
+    if !t.separateStream {
+        return nil
+    }
+
+    symbols := t.symbols()
+    u := url.URL{Scheme: "wss", Host: "stream.exchange.example", Path: "/ws"}
+
+    conn, _, err := websocket.DefaultDialer.DialContext(ctx, u.String(), nil)
+    if err != nil {
+        return fmt.Errorf("dial %s: %w", u.String(), err)
+    }
+    t.conn = conn
+
+    for _, symbol := range symbols {
+        t.booksMutex.Lock()
+        t.books[symbol] = &OrderBookSnapshot{Symbol: symbol}
+        t.booksMutex.Unlock()
+    }
+
+    go t.readLoop(ctx)
+    return nil
+}
+
+func (t *TriangularArbitrage) readLoop(ctx context.Context) {
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        default:
+            _, msg, err := t.conn.ReadMessage()
+            if err != nil {
+                log.Printf("⚠️ Triangular Arbitrage: stream read error: %v", err)
+                return
+            }
+            t.handleBookUpdate(msg)
+        }
+    }
+}
+
+// handleBookUpdate parses a single depth update. Exchange-specific wire
+// format is intentionally left as a stub.
+func (t *TriangularArbitrage) handleBookUpdate(msg []byte) {
+    _ = msg
+}
+
+func (t *TriangularArbitrage) symbols() []string {
+    seen := make(map[string]bool)
+    var out []string
+    for _, p := range t.paths {
+        for _, leg := range p.Legs {
+            if !seen[leg] {
+                seen[leg] = true
+                out = append(out, leg)
+            }
+        }
+    }
+    return out
+}
+
+func (t *TriangularArbitrage) snapshot(symbol string) (*OrderBookSnapshot, bool) {
+    t.booksMutex.RLock()
+    defer t.booksMutex.RUnlock()
+    book, ok := t.books[symbol]
+    return book, ok
+}
+
+// evaluateCycle walks the path the same buy/sell direction executeCycle
+// derives via legSide, chaining each leg's forward conversion (1/Ask when
+// buying into a leg, Bid when selling out of it) to get the true
+// round-trip return on one unit of the path's starting asset, net of the
+// per-leg taker fee. It also converts each leg's top-of-book depth back
+// into starting-asset units (undoing the same chain of conversions) so the
+// cycle is sized off the shallowest leg without mixing units across legs
+// denominated in different assets.
+func (t *TriangularArbitrage) evaluateCycle(path ArbPath) (*CycleOpportunity, bool) {
+    if len(path.Legs) == 0 {
+        return nil, false
+    }
+
+    _, asset := splitSymbol(path.Legs[0])
+    netRatio := 1.0
+    cumulativeFactor := 1.0 // starting-asset units -> units of `asset` held entering the next leg
+    maxStartSize := decimal.NewFromInt(1 << 30) // effectively unbounded until clamped below
+
+    for _, leg := range path.Legs {
+        book, ok := t.snapshot(leg)
+        if !ok || book.BestBid.Price.IsZero() || book.BestAsk.Price.IsZero() {
+            return nil, false
+        }
+
+        base, quote := splitSymbol(leg)
+        side, nextAsset, err := legSide(asset, base, quote)
+        if err != nil {
+            return nil, false
+        }
+
+        var legFactor float64
+        var depthInHoldingUnits decimal.Decimal
+        switch side {
+        case "buy":
+            legFactor = 1 / book.BestAsk.Price.InexactFloat64()
+            depthInHoldingUnits = book.BestAsk.Size.Mul(book.BestAsk.Price)
+        case "sell":
+            legFactor = book.BestBid.Price.InexactFloat64()
+            depthInHoldingUnits = book.BestBid.Size
+        }
+        netRatio *= legFactor * (1 - t.feeRate)
+
+        startLimit := depthInHoldingUnits.Div(decimal.NewFromFloat(cumulativeFactor))
+        if startLimit.LessThan(maxStartSize) {
+            maxStartSize = startLimit
+        }
+
+        cumulativeFactor *= legFactor
+        asset = nextAsset
+    }
+
+    if netRatio <= path.MinSpreadRatio {
+        return nil, false
+    }
+
+    return &CycleOpportunity{
+        Path:       path,
+        NetRatio:   netRatio,
+        MaxSize:    t.clampToExposure(path, maxStartSize),
+        DetectedAt: time.Now(),
+    }, true
+}
+
+// clampToExposure shrinks size (denominated in the quote asset of the
+// path's first leg) so that, walking the cycle leg by leg, the quantity
+// held at no point exceeds that asset's configured exposure limit. Each
+// leg's holding is converted through its own book price before being
+// checked against exposureLimits, so e.g. a BTC-denominated limit is
+// never compared directly against an ETH or USDT quantity.
+func (t *TriangularArbitrage) clampToExposure(path ArbPath, size decimal.Decimal) decimal.Decimal {
+    if len(path.Legs) == 0 {
+        return size
+    }
+
+    _, asset := splitSymbol(path.Legs[0])
+    qty := size
+    scale := decimal.NewFromInt(1)
+
+    for _, leg := range path.Legs {
+        base, quote := splitSymbol(leg)
+        side, nextAsset, err := legSide(asset, base, quote)
+        if err != nil {
+            break
+        }
+
+        if limit, ok := t.exposureLimits[asset]; ok && !qty.IsZero() {
+            limitDec := decimal.NewFromFloat(limit)
+            if qty.GreaterThan(limitDec) {
+                if legScale := limitDec.Div(qty); legScale.LessThan(scale) {
+                    scale = legScale
+                }
+            }
+        }
+
+        book, ok := t.snapshot(leg)
+        if !ok {
+            break
+        }
+        switch side {
+        case "buy":
+            qty = qty.Div(book.BestAsk.Price)
+        case "sell":
+            qty = qty.Mul(book.BestBid.Price)
+        }
+        asset = nextAsset
+    }
+
+    return size.Mul(scale)
+}
+
+// executeCycle submits every leg of the path atomically, deriving each
+// leg's side from the asset held entering it (e.g. for
+// [BTCUSDT, ETHBTC, ETHUSDT] starting in USDT: buy BTCUSDT, buy ETHBTC,
+// sell ETHUSDT, round-tripping back to USDT). If any leg fails, the legs
+// already filled are rolled back via opposing market orders.
+func (t *TriangularArbitrage) executeCycle(ctx context.Context, opp *CycleOpportunity) {
+    log.Printf("🔺 Triangular opportunity: %v net=%.5f size=%s", opp.Path.Legs, opp.NetRatio, opp.MaxSize)
+
+    if len(opp.Path.Legs) == 0 {
+        return
+    }
+
+    var filled []*Order
+    orderGroupID := fmt.Sprintf("tri-%d", time.Now().UnixNano())
+
+    _, asset := splitSymbol(opp.Path.Legs[0])
+    qty := opp.MaxSize
+
+    for _, leg := range opp.Path.Legs {
+        base, quote := splitSymbol(leg)
+        side, nextAsset, err := legSide(asset, base, quote)
+        if err != nil {
+            log.Printf("⚠️ Triangular Arbitrage: %v", err)
+            t.rollback(filled)
+            return
+        }
+
+        order := &Order{
+            Symbol:      leg,
+            PatternHash: orderGroupID,
+            Side:        side,
+            Size:        qty.InexactFloat64(),
+            Timestamp:   time.Now(),
+        }
+
+        result := t.orderRouter.Execute(order)
+        if result == nil || !result.Profitable {
+            t.rollback(filled)
+            return
+        }
+        filled = append(filled, order)
+
+        if book, ok := t.snapshot(leg); ok {
+            switch side {
+            case "buy":
+                qty = qty.Div(book.BestAsk.Price)
+            case "sell":
+                qty = qty.Mul(book.BestBid.Price)
+            }
+        }
+        asset = nextAsset
+    }
+}
+
+// rollback unwinds already-filled legs of a cycle that failed partway
+// through by submitting an opposing order for each, most recent leg first.
+func (t *TriangularArbitrage) rollback(filled []*Order) {
+    if len(filled) == 0 {
+        return
+    }
+    log.Printf("⚠️ Triangular Arbitrage: rolling back %d filled leg(s)", len(filled))
+    for i := len(filled) - 1; i >= 0; i-- {
+        leg := filled[i]
+        unwind := &Order{
+            PatternHash: leg.PatternHash,
+            Side:        oppositeSide(leg.Side),
+            Size:        leg.Size,
+            Timestamp:   time.Now(),
+        }
+        t.orderRouter.Execute(unwind)
+    }
+}
+
+func oppositeSide(side string) string {
+    if side == "buy" {
+        return "sell"
+    }
+    return "buy"
+}
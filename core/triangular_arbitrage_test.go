@@ -0,0 +1,155 @@
+package main
+
+import (
+    "math"
+    "testing"
+
+    "github.com/shopspring/decimal"
+)
+
+func level(price, size float64) OrderBookLevel {
+    return OrderBookLevel{Price: decimal.NewFromFloat(price), Size: decimal.NewFromFloat(size)}
+}
+
+func TestSplitSymbol(t *testing.T) {
+    cases := []struct {
+        symbol    string
+        wantBase  string
+        wantQuote string
+    }{
+        {"BTCUSDT", "BTC", "USDT"},
+        {"ETHBTC", "ETH", "BTC"},
+        {"ETHUSDT", "ETH", "USDT"},
+        {"BNBBTC", "BNB", "BTC"},
+        {"XYZ", "XYZ", ""}, // no known quote suffix
+    }
+    for _, c := range cases {
+        base, quote := splitSymbol(c.symbol)
+        if base != c.wantBase || quote != c.wantQuote {
+            t.Errorf("splitSymbol(%q) = (%q, %q), want (%q, %q)", c.symbol, base, quote, c.wantBase, c.wantQuote)
+        }
+    }
+}
+
+func TestLegSide(t *testing.T) {
+    side, next, err := legSide("USDT", "BTC", "USDT")
+    if err != nil || side != "buy" || next != "BTC" {
+        t.Errorf("legSide(USDT, BTC, USDT) = (%q, %q, %v), want (buy, BTC, nil)", side, next, err)
+    }
+
+    side, next, err = legSide("BTC", "BTC", "USDT")
+    if err != nil || side != "sell" || next != "USDT" {
+        t.Errorf("legSide(BTC, BTC, USDT) = (%q, %q, %v), want (sell, USDT, nil)", side, next, err)
+    }
+
+    if _, _, err := legSide("ETH", "BTC", "USDT"); err == nil {
+        t.Error("legSide(ETH, BTC, USDT) = nil error, want an error (ETH isn't part of the leg)")
+    }
+}
+
+// TestEvaluateCycleProfitableRoundTrip covers a hand-built order book where
+// buying BTC with USDT, buying ETH with BTC, then selling ETH for USDT
+// nets a real profit. This is the scenario the old Bid/Ask-ratio-product
+// formula could never fire on: it multiplied ratios that are always <=1
+// regardless of direction, so no market condition could clear
+// MinSpreadRatio > 1.
+func TestEvaluateCycleProfitableRoundTrip(t *testing.T) {
+    tri := NewTriangularArbitrage(nil, nil, false, NewOrderRouter())
+    tri.books["BTCUSDT"] = &OrderBookSnapshot{Symbol: "BTCUSDT", BestBid: level(99, 10), BestAsk: level(100, 2)}
+    tri.books["ETHBTC"] = &OrderBookSnapshot{Symbol: "ETHBTC", BestBid: level(0.0495, 10), BestAsk: level(0.05, 3)}
+    tri.books["ETHUSDT"] = &OrderBookSnapshot{Symbol: "ETHUSDT", BestBid: level(5.06, 1), BestAsk: level(5.10, 10)}
+
+    path := ArbPath{Legs: []string{"BTCUSDT", "ETHBTC", "ETHUSDT"}, MinSpreadRatio: 1.0011}
+
+    opp, ok := tri.evaluateCycle(path)
+    if !ok {
+        t.Fatal("evaluateCycle returned ok=false for a profitable round trip")
+    }
+
+    wantNetRatio := (1.0 / 100) * 0.999 * (1.0 / 0.05) * 0.999 * 5.06 * 0.999
+    if math.Abs(opp.NetRatio-wantNetRatio) > 1e-9 {
+        t.Errorf("NetRatio = %v, want %v", opp.NetRatio, wantNetRatio)
+    }
+
+    // Depth is capped by the shallowest leg once converted back into
+    // USDT (the path's starting asset): 2 BTC of ask depth on the first
+    // leg is worth 200 USDT; 3 ETH of ask depth on the second leg is
+    // worth 0.15 BTC, i.e. 15 USDT at the first leg's price; 1 ETH of bid
+    // depth on the third leg is worth 5 USDT once converted all the way
+    // back through both legs. The tightest constraint (5) wins.
+    wantMaxSize := 5.0
+    gotMaxSize, _ := opp.MaxSize.Float64()
+    if math.Abs(gotMaxSize-wantMaxSize) > 1e-9 {
+        t.Errorf("MaxSize = %v, want %v", gotMaxSize, wantMaxSize)
+    }
+}
+
+// TestEvaluateCycleBelowThreshold covers a book whose true round-trip
+// return does not clear MinSpreadRatio.
+func TestEvaluateCycleBelowThreshold(t *testing.T) {
+    tri := NewTriangularArbitrage(nil, nil, false, NewOrderRouter())
+    tri.books["BTCUSDT"] = &OrderBookSnapshot{Symbol: "BTCUSDT", BestBid: level(99.9, 10), BestAsk: level(100, 2)}
+    tri.books["ETHBTC"] = &OrderBookSnapshot{Symbol: "ETHBTC", BestBid: level(0.04999, 10), BestAsk: level(0.05, 3)}
+    tri.books["ETHUSDT"] = &OrderBookSnapshot{Symbol: "ETHUSDT", BestBid: level(5.0, 1), BestAsk: level(5.001, 10)}
+
+    path := ArbPath{Legs: []string{"BTCUSDT", "ETHBTC", "ETHUSDT"}, MinSpreadRatio: 1.0011}
+
+    if _, ok := tri.evaluateCycle(path); ok {
+        t.Fatal("evaluateCycle returned ok=true for a round trip that doesn't clear MinSpreadRatio")
+    }
+}
+
+// TestEvaluateCycleMissingBook covers a leg with no recorded snapshot yet.
+func TestEvaluateCycleMissingBook(t *testing.T) {
+    tri := NewTriangularArbitrage(nil, nil, false, NewOrderRouter())
+    tri.books["BTCUSDT"] = &OrderBookSnapshot{Symbol: "BTCUSDT", BestBid: level(99, 10), BestAsk: level(100, 2)}
+
+    path := ArbPath{Legs: []string{"BTCUSDT", "ETHBTC", "ETHUSDT"}, MinSpreadRatio: 1.0011}
+
+    if _, ok := tri.evaluateCycle(path); ok {
+        t.Fatal("evaluateCycle returned ok=true with a leg missing its order book")
+    }
+}
+
+// TestClampToExposureScalesDownForBindingAsset covers exposure limits
+// being converted through each leg's own asset rather than compared
+// directly against a size denominated in a different asset.
+func TestClampToExposureScalesDownForBindingAsset(t *testing.T) {
+    exposureLimits := map[string]float64{"BTC": 0.02} // binds on leg 2, not leg 1's USDT units
+    tri := NewTriangularArbitrage(nil, exposureLimits, false, NewOrderRouter())
+    tri.books["BTCUSDT"] = &OrderBookSnapshot{Symbol: "BTCUSDT", BestBid: level(99, 10), BestAsk: level(100, 2)}
+    tri.books["ETHBTC"] = &OrderBookSnapshot{Symbol: "ETHBTC", BestBid: level(0.0495, 10), BestAsk: level(0.05, 3)}
+    tri.books["ETHUSDT"] = &OrderBookSnapshot{Symbol: "ETHUSDT", BestBid: level(5.06, 1), BestAsk: level(5.10, 10)}
+
+    path := ArbPath{Legs: []string{"BTCUSDT", "ETHBTC", "ETHUSDT"}}
+
+    // 10 USDT converts to 0.1 BTC entering leg 2, which exceeds the 0.02
+    // BTC limit; clampToExposure must scale the whole size down so leg
+    // 2's BTC holding stays within the limit, not compare 10 (USDT) or
+    // the raw order size directly against 0.02 (BTC).
+    got := tri.clampToExposure(path, decimal.NewFromFloat(10))
+
+    wantScale := 0.02 / 0.1 // limit / BTC qty that 10 USDT would buy
+    want := 10 * wantScale
+    gotFloat, _ := got.Float64()
+    if math.Abs(gotFloat-want) > 1e-9 {
+        t.Errorf("clampToExposure = %v, want %v", gotFloat, want)
+    }
+}
+
+// TestClampToExposureNoLimitsIsNoOp covers the common case of an empty
+// exposureLimits map leaving size untouched.
+func TestClampToExposureNoLimitsIsNoOp(t *testing.T) {
+    tri := NewTriangularArbitrage(nil, nil, false, NewOrderRouter())
+    tri.books["BTCUSDT"] = &OrderBookSnapshot{Symbol: "BTCUSDT", BestBid: level(99, 10), BestAsk: level(100, 2)}
+    tri.books["ETHBTC"] = &OrderBookSnapshot{Symbol: "ETHBTC", BestBid: level(0.0495, 10), BestAsk: level(0.05, 3)}
+    tri.books["ETHUSDT"] = &OrderBookSnapshot{Symbol: "ETHUSDT", BestBid: level(5.06, 1), BestAsk: level(5.10, 10)}
+
+    path := ArbPath{Legs: []string{"BTCUSDT", "ETHBTC", "ETHUSDT"}}
+
+    got := tri.clampToExposure(path, decimal.NewFromFloat(10))
+    gotFloat, _ := got.Float64()
+    if math.Abs(gotFloat-10) > 1e-9 {
+        t.Errorf("clampToExposure with no limits = %v, want 10 (unchanged)", gotFloat)
+    }
+}